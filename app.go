@@ -1,23 +1,33 @@
 package jadepoolsaas
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 // NewApp creates a new wallet with key and secret.
-func NewApp(appKey, appSecret string) *App {
-	return NewAppWithAddr(defaultAddr, appKey, appSecret)
+func NewApp(appKey, appSecret string, opts ...Option) *App {
+	return NewAppWithAddr(defaultAddr, appKey, appSecret, opts...)
 }
 
 // NewAppWithAddr creates a new wallet with server addr, key and secret.
-func NewAppWithAddr(addr, appKey, appSecret string) *App {
+func NewAppWithAddr(addr, appKey, appSecret string, opts ...Option) *App {
 	a := &App{
 		Addr:   addr,
 		Key:    appKey,
 		Secret: appSecret,
 	}
-	a.session = &session{client: a}
+	a.session = newSession(a, opts)
 	return a
 }
 
+// WithContext returns a shallow copy of the wallet whose requests are bound to ctx.
+func (a *App) WithContext(ctx context.Context) *App {
+	copied := *a
+	copied.session = a.session.withContext(&copied, ctx)
+	return &copied
+}
+
 // CreateAddress request new address.
 func (a *App) CreateAddress(coinType string) (*Result, error) {
 	return a.CreateAddressWithMode(coinType, "")
@@ -332,6 +342,10 @@ type App struct {
 	session *session
 }
 
+func (a *App) getKind() string {
+	return "app"
+}
+
 func (a *App) getKey() string {
 	return a.Key
 }
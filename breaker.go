@@ -0,0 +1,246 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the server while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+// Circuit breaker states.
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerSettings configures a circuit breaker, zero fields take the defaults.
+type BreakerSettings struct {
+	// FailureRatio opens the circuit when reached by the failed calls in the window, defaults to 0.5.
+	FailureRatio float64
+	// MinRequests is the number of calls in the window before the ratio is evaluated, defaults to 10.
+	MinRequests int
+	// Window is the period counting calls while closed, defaults to 1 minute.
+	Window time.Duration
+	// OpenTimeout is the period the circuit stays open before probing, defaults to 30 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of successful probes closing the circuit, defaults to 1.
+	HalfOpenRequests int
+	// OnStateChange is called after every state change.
+	OnStateChange func(from, to CircuitState)
+	// IsFailure reports whether a call failed, defaults to any error. Calls
+	// canceled by their caller are never counted.
+	IsFailure func(call *Call, err error) bool
+}
+
+// CircuitBreaker fails calls fast while the server keeps failing.
+type CircuitBreaker struct {
+	settings BreakerSettings
+
+	mu          sync.Mutex
+	state       CircuitState
+	generation  int
+	windowStart time.Time
+	openedAt    time.Time
+	requests    int
+	failures    int
+	probes      int
+	successes   int
+}
+
+// NewCircuitBreaker creates a closed circuit breaker.
+func NewCircuitBreaker(settings BreakerSettings) *CircuitBreaker {
+	if settings.FailureRatio <= 0 {
+		settings.FailureRatio = 0.5
+	}
+	if settings.MinRequests <= 0 {
+		settings.MinRequests = 10
+	}
+	if settings.Window <= 0 {
+		settings.Window = time.Minute
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 30 * time.Second
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = 1
+	}
+	if settings.IsFailure == nil {
+		settings.IsFailure = func(call *Call, err error) bool {
+			return err != nil
+		}
+	}
+
+	return &CircuitBreaker{
+		settings:    settings,
+		windowStart: time.Now(),
+	}
+}
+
+// State returns the current state of the circuit breaker.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	from := cb.state
+	state, _ := cb.currentState(time.Now())
+	cb.mu.Unlock()
+
+	cb.notify(from, state)
+	return state
+}
+
+// Interceptor returns the interceptor guarding calls with the circuit breaker.
+func (cb *CircuitBreaker) Interceptor() Interceptor {
+	return func(next RoundTrip) RoundTrip {
+		return func(call *Call) error {
+			generation, err := cb.before()
+			if err != nil {
+				return err
+			}
+
+			err = next(call)
+			if errors.Is(err, context.Canceled) {
+				cb.release(generation)
+				return err
+			}
+			cb.after(generation, cb.settings.IsFailure(call, err))
+			return err
+		}
+	}
+}
+
+// WithCircuitBreaker guards the calls of the client with cb.
+func WithCircuitBreaker(cb *CircuitBreaker) Option {
+	return WithInterceptors(cb.Interceptor())
+}
+
+func (cb *CircuitBreaker) before() (int, error) {
+	cb.mu.Lock()
+	from := cb.state
+	state, generation := cb.currentState(time.Now())
+
+	var err error
+	switch state {
+	case CircuitOpen:
+		err = ErrCircuitOpen
+	case CircuitHalfOpen:
+		if cb.probes >= cb.settings.HalfOpenRequests {
+			err = ErrCircuitOpen
+		} else {
+			cb.probes++
+		}
+	default:
+		cb.requests++
+	}
+	cb.mu.Unlock()
+
+	cb.notify(from, state)
+	return generation, err
+}
+
+func (cb *CircuitBreaker) after(generation int, failed bool) {
+	cb.mu.Lock()
+	now := time.Now()
+	from := cb.state
+	state, current := cb.currentState(now)
+	if generation != current {
+		cb.mu.Unlock()
+		return
+	}
+
+	switch state {
+	case CircuitClosed:
+		if failed {
+			cb.failures++
+		}
+		if cb.requests >= cb.settings.MinRequests &&
+			float64(cb.failures)/float64(cb.requests) >= cb.settings.FailureRatio {
+			cb.setState(CircuitOpen, now)
+		}
+	case CircuitHalfOpen:
+		if failed {
+			cb.setState(CircuitOpen, now)
+		} else {
+			cb.successes++
+			if cb.successes >= cb.settings.HalfOpenRequests {
+				cb.setState(CircuitClosed, now)
+			}
+		}
+	}
+	to := cb.state
+	cb.mu.Unlock()
+
+	cb.notify(from, to)
+}
+
+// release uncounts a call canceled by its caller, freeing its probe slot
+// while half-open.
+func (cb *CircuitBreaker) release(generation int) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if generation != cb.generation {
+		return
+	}
+
+	switch cb.state {
+	case CircuitClosed:
+		cb.requests--
+	case CircuitHalfOpen:
+		cb.probes--
+	}
+}
+
+// currentState moves an expired open circuit to half-open and resets an expired window.
+func (cb *CircuitBreaker) currentState(now time.Time) (CircuitState, int) {
+	switch cb.state {
+	case CircuitClosed:
+		if now.Sub(cb.windowStart) >= cb.settings.Window {
+			cb.generation++
+			cb.windowStart = now
+			cb.requests = 0
+			cb.failures = 0
+		}
+	case CircuitOpen:
+		if now.Sub(cb.openedAt) >= cb.settings.OpenTimeout {
+			cb.setState(CircuitHalfOpen, now)
+		}
+	}
+	return cb.state, cb.generation
+}
+
+func (cb *CircuitBreaker) setState(state CircuitState, now time.Time) {
+	cb.state = state
+	cb.generation++
+	cb.windowStart = now
+	cb.requests = 0
+	cb.failures = 0
+	cb.probes = 0
+	cb.successes = 0
+	if state == CircuitOpen {
+		cb.openedAt = now
+	}
+}
+
+func (cb *CircuitBreaker) notify(from, to CircuitState) {
+	if from != to && cb.settings.OnStateChange != nil {
+		cb.settings.OnStateChange(from, to)
+	}
+}
@@ -0,0 +1,104 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	response := map[string]interface{}{}
+
+	var healthy int32
+	var hits int32
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, err := writeSuccessResponse(w, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	var transitions []string
+	cb := NewCircuitBreaker(BreakerSettings{
+		MinRequests: 2,
+		OpenTimeout: 50 * time.Millisecond,
+		OnStateChange: func(from, to CircuitState) {
+			transitions = append(transitions, from.String()+">"+to.String())
+		},
+	})
+	app := NewAppWithAddr(ts.URL, TestAppKey, TestAppSecret, WithCircuitBreaker(cb))
+
+	for i := 0; i < 2; i++ {
+		if _, err := app.GetBalance("ETH"); err == nil {
+			t.Fatal("want http error")
+		}
+	}
+	if cb.State() != CircuitOpen {
+		t.Fatalf("state = %s; want open", cb.State())
+	}
+
+	_, err := app.GetBalance("ETH")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v; want ErrCircuitOpen", err)
+	}
+	if hits != 2 {
+		t.Errorf("hits = %d; want 2", hits)
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(60 * time.Millisecond)
+	if _, err = app.GetBalance("ETH"); err != nil {
+		t.Fatal(err)
+	}
+	if cb.State() != CircuitClosed {
+		t.Fatalf("state = %s; want closed", cb.State())
+	}
+
+	want := []string{"closed>open", "open>half-open", "half-open>closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v; want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transitions = %v; want %v", transitions, want)
+		}
+	}
+}
+
+func TestCircuitBreakerCanceledProbe(t *testing.T) {
+	cb := NewCircuitBreaker(BreakerSettings{MinRequests: 1, OpenTimeout: 10 * time.Millisecond})
+	fail := cb.Interceptor()(func(call *Call) error { return errors.New("unavailable") })
+	cancel := cb.Interceptor()(func(call *Call) error { return context.Canceled })
+	succeed := cb.Interceptor()(func(call *Call) error { return nil })
+
+	fail(&Call{})
+	if cb.State() != CircuitOpen {
+		t.Fatalf("state = %s; want open", cb.State())
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if err := cancel(&Call{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v; want canceled", err)
+	}
+	if cb.State() != CircuitHalfOpen {
+		t.Fatalf("state = %s; want half-open after a canceled probe", cb.State())
+	}
+	if err := succeed(&Call{}); err != nil {
+		t.Fatalf("err = %v; want the probe slot released", err)
+	}
+	if cb.State() != CircuitClosed {
+		t.Errorf("state = %s; want closed", cb.State())
+	}
+}
@@ -0,0 +1,374 @@
+package jadepoolsaas
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sync"
+	"unicode/utf8"
+)
+
+// volatileFields change on every request and are ignored when matching.
+var volatileFields = map[string]bool{
+	"timestamp": true,
+	"nonce":     true,
+	"sign":      true,
+	"aesIV":     true,
+}
+
+// Cassette holds request/response pairs recorded from real traffic.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request together with its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the redacted form of a request sent by the sdk.
+type RecordedRequest struct {
+	Method string                 `json:"method"`
+	Path   string                 `json:"path"`
+	Header map[string]string      `json:"header,omitempty"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// RecordedResponse is the redacted form of a server response.
+type RecordedResponse struct {
+	StatusCode int               `json:"statusCode"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       string            `json:"body"`
+	Encoding   string            `json:"encoding,omitempty"`
+}
+
+// LoadCassette reads a cassette file.
+func LoadCassette(path string) (*Cassette, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+	var c Cassette
+	if err = decoder.Decode(&c); err != nil {
+		return nil, fmt.Errorf("parse cassette failed: %v", err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path.
+func (c *Cassette) Save(path string) error {
+	buf, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf, 0600)
+}
+
+// Recorder is a http.RoundTripper recording every request/response pair
+// into a cassette, with secrets and the specified fields redacted.
+type Recorder struct {
+	path      string
+	transport http.RoundTripper
+	redactor  redactor
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder creates a recorder sending requests through transport and
+// saving interactions to path. Extra PII fields to redact can be specified.
+func NewRecorder(path string, transport http.RoundTripper, redactFields ...string) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &Recorder{
+		path:      path,
+		transport: transport,
+		redactor:  newRedactor(secretFields, redactFields),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	params, err := readRequestParams(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	interaction := &Interaction{
+		Request: RecordedRequest{
+			Method: request.Method,
+			Path:   request.URL.Path,
+			Header: r.redactor.header(request.Header),
+			Params: r.redactor.value(params).(map[string]interface{}),
+		},
+		Response: RecordedResponse{
+			StatusCode: response.StatusCode,
+			Header:     r.redactor.header(response.Header),
+		},
+	}
+	interaction.Response.Body, interaction.Response.Encoding = r.encodeBody(body)
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return response, nil
+}
+
+// Cassette returns the interactions recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := &Cassette{Interactions: make([]*Interaction, len(r.cassette.Interactions))}
+	copy(c.Interactions, r.cassette.Interactions)
+	return c
+}
+
+// Save writes the recorded interactions to the cassette file.
+func (r *Recorder) Save() error {
+	return r.Cassette().Save(r.path)
+}
+
+func (r *Recorder) encodeBody(body []byte) (string, string) {
+	obj, err := decodeJSONObject(body)
+	if err == nil {
+		buf, err := json.Marshal(r.redactor.value(obj))
+		if err == nil {
+			return string(buf), ""
+		}
+	}
+
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// Replayer is a http.RoundTripper answering requests from a cassette.
+// Volatile fields such as timestamp, nonce and sign are ignored when matching,
+// and replayed responses are re-signed with the secret so they pass checkSign.
+type Replayer struct {
+	secret string
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewReplayer creates a replayer from the cassette file at path.
+func NewReplayer(path, secret string) (*Replayer, error) {
+	c, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayerWithCassette(c, secret), nil
+}
+
+// NewReplayerWithCassette creates a replayer from a loaded cassette.
+func NewReplayerWithCassette(c *Cassette, secret string) *Replayer {
+	return &Replayer{
+		secret:   secret,
+		cassette: c,
+		used:     make([]bool, len(c.Interactions)),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(request *http.Request) (*http.Response, error) {
+	params, err := readRequestParams(request)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := r.match(request.Method, request.URL.Path, params)
+	if interaction == nil {
+		return nil, fmt.Errorf("cassette: no interaction recorded for %s %s", request.Method, request.URL.Path)
+	}
+
+	body, err := r.decodeBody(interaction.Response)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make(http.Header)
+	for key, value := range interaction.Response.Header {
+		header.Set(key, value)
+	}
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}, nil
+}
+
+func (r *Replayer) match(method, path string, params map[string]interface{}) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := -1
+	for i, interaction := range r.cassette.Interactions {
+		if interaction.Request.Method != method || interaction.Request.Path != path {
+			continue
+		}
+		if !matchParams(interaction.Request.Params, params) {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return interaction
+		}
+		last = i
+	}
+
+	if last < 0 {
+		return nil
+	}
+	return r.cassette.Interactions[last]
+}
+
+func (r *Replayer) decodeBody(response RecordedResponse) ([]byte, error) {
+	if response.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(response.Body)
+	}
+
+	body := []byte(response.Body)
+	obj, err := decodeJSONObject(body)
+	if err != nil {
+		return body, nil
+	}
+	if _, ok := obj["sign"]; !ok {
+		return body, nil
+	}
+
+	sign, err := signHMACSHA256(obj["data"], r.secret)
+	if err != nil {
+		return nil, err
+	}
+	obj["sign"] = sign
+	return json.Marshal(obj)
+}
+
+func matchParams(recorded, actual map[string]interface{}) bool {
+	for key, val := range recorded {
+		if volatileFields[key] || val == redactedValue {
+			continue
+		}
+		if !matchValue(val, actual[key]) {
+			return false
+		}
+	}
+	for key := range actual {
+		if _, ok := recorded[key]; !ok && !volatileFields[key] {
+			return false
+		}
+	}
+	return true
+}
+
+func matchValue(recorded, actual interface{}) bool {
+	a, err := json.Marshal(recorded)
+	if err != nil {
+		return false
+	}
+	b, err := json.Marshal(actual)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(a, b)
+}
+
+// readRequestParams collects query, json body and multipart fields of the
+// request, leaving the body readable for the next transport.
+func readRequestParams(request *http.Request) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	for key := range request.URL.Query() {
+		params[key] = request.URL.Query().Get(key)
+	}
+
+	if request.Body == nil {
+		return params, nil
+	}
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	request.ContentLength = int64(len(body))
+
+	mediaType, mediaParams, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		reader := multipart.NewReader(bytes.NewReader(body), mediaParams["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if part.FileName() != "" {
+				params[part.FormName()] = part.FileName()
+				continue
+			}
+			val, err := ioutil.ReadAll(part)
+			if err != nil {
+				return nil, err
+			}
+			params[part.FormName()] = string(val)
+		}
+	default:
+		obj, err := decodeJSONObject(body)
+		if err != nil {
+			break
+		}
+		for key, val := range obj {
+			params[key] = val
+		}
+	}
+	return params, nil
+}
+
+func decodeJSONObject(buf []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+	obj := make(map[string]interface{})
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
@@ -0,0 +1,93 @@
+package jadepoolsaas
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	coin := "ETH"
+	address := "0x7C3A4d3ff2b92CFDD2eD1a105d5bAc8fAF4008aE"
+	response := map[string]interface{}{
+		"address": address,
+		"owner":   "Alice",
+	}
+
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		_, err := writeSuccessResponse(w, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "address.json")
+
+	recorder := NewRecorder(path, nil, "owner")
+	app := NewAppWithAddr(ts.URL, TestAppKey, TestAppSecret, WithHTTPClient(&http.Client{Transport: recorder}))
+	_, err = app.VerifyAddress(coin, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"Alice", TestAppKey} {
+		if strings.Contains(string(buf), secret) {
+			t.Errorf("cassette contains %s", secret)
+		}
+	}
+
+	c, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Interactions) != 1 {
+		t.Fatalf("interactions = %d; want 1", len(c.Interactions))
+	}
+	if c.Interactions[0].Request.Params["sign"] != redactedValue {
+		t.Error("request sign is not redacted")
+	}
+	if !strings.Contains(c.Interactions[0].Response.Body, `"sign":"REDACTED"`) {
+		t.Error("response sign is not redacted")
+	}
+
+	replayer, err := NewReplayer(path, TestAppSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app = NewAppWithAddr(ts.URL, TestAppKey, TestAppSecret, WithHTTPClient(&http.Client{Transport: replayer}))
+	ts.Close()
+
+	result, err := app.VerifyAddress(coin, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Data["address"] != address {
+		t.Errorf("address = %v; want %s", result.Data["address"], address)
+	}
+	if result.Data["owner"] != redactedValue {
+		t.Errorf("owner = %v; want %s", result.Data["owner"], redactedValue)
+	}
+
+	_, err = app.VerifyAddress(coin, "0x9bf65CDF5729b9588F6bAEBb2Aa2926472D4a035")
+	if err == nil {
+		t.Error("replay unrecorded request succeeded; want error")
+	}
+}
@@ -1,6 +1,7 @@
 package jadepoolsaas
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"errors"
@@ -8,21 +9,28 @@ import (
 )
 
 // NewCompany creates a new company with key and secret.
-func NewCompany(key, secret string) *Company {
-	return NewCompanyWithAddr(defaultAddr, key, secret)
+func NewCompany(key, secret string, opts ...Option) *Company {
+	return NewCompanyWithAddr(defaultAddr, key, secret, opts...)
 }
 
 // NewCompanyWithAddr creates a new company with server addr, key and secret.
-func NewCompanyWithAddr(addr, key, secret string) *Company {
+func NewCompanyWithAddr(addr, key, secret string, opts ...Option) *Company {
 	a := &Company{
 		Addr:   addr,
 		Key:    key,
 		Secret: secret,
 	}
-	a.session = &session{client: a}
+	a.session = newSession(a, opts)
 	return a
 }
 
+// WithContext returns a shallow copy of the company whose requests are bound to ctx.
+func (c *Company) WithContext(ctx context.Context) *Company {
+	copied := *c
+	copied.session = c.session.withContext(&copied, ctx)
+	return &copied
+}
+
 // GetFundingWallets get all wallets in the company.
 func (c *Company) GetFundingWallets() (*Result, error) {
 	return c.session.get("/api/v1/funding/balances")
@@ -196,6 +204,10 @@ type Company struct {
 	session *session
 }
 
+func (c *Company) getKind() string {
+	return "company"
+}
+
 func (c *Company) getKey() string {
 	return c.Key
 }
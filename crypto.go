@@ -9,6 +9,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
@@ -136,6 +137,9 @@ func padding(src []byte, blockSize int) []byte {
 
 func aesDecryptStr(src string, key, iv []byte) (string, error) {
 	bsrc, err := base64.StdEncoding.DecodeString(src)
+	if err != nil {
+		return "", err
+	}
 	bret, err := aesDecrypt(bsrc, key, iv)
 	if err != nil {
 		return "", err
@@ -151,6 +155,9 @@ func aesDecrypt(src []byte, key []byte, iv []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(src) == 0 || len(src)%block.BlockSize() != 0 {
+		return nil, errors.New("invalid ciphertext length")
+	}
 	blockMode := cipher.NewCBCDecrypter(block, iv)
 	blockMode.CryptBlocks(src, src)
 	src = unpadding(src)
@@ -0,0 +1,112 @@
+package jadepoolsaas
+
+import "strings"
+
+// endpoint maps a path template to a stable name, segments in braces are variables.
+type endpoint struct {
+	method   string
+	template string
+	name     string
+}
+
+var endpoints = map[string][]endpoint{
+	"app": {
+		{"POST", "/api/v1/address/{coinType}/new", "address.create"},
+		{"POST", "/api/v1/address/{coinType}/verify", "address.verify"},
+		{"POST", "/api/v1/address/{coinType}/check", "address.check"},
+		{"GET", "/api/v1/address/{coinType}", "address.get"},
+		{"GET", "/api/v1/app/allAssets", "assets.all"},
+		{"GET", "/api/v1/app/assetsWithID", "assets.list"},
+		{"GET", "/api/v1/app/info", "info"},
+		{"POST", "/api/v1/app/assets", "assets.add"},
+		{"GET", "/api/v1/app/balances", "balances"},
+		{"GET", "/api/v1/app/balance/{coinType}", "balance"},
+		{"GET", "/api/v1/app/orders", "orders"},
+		{"GET", "/api/v1/app/order/{id}", "order.get"},
+		{"PUT", "/api/v1/app/order/{id}", "order.update"},
+		{"POST", "/api/v1/app/{coinType}/withdraw", "withdraw"},
+		{"POST", "/api/v1/app/{coinType}/transfer", "transfer"},
+		{"POST", "/api/v1/staking/{coinType}/delegate", "staking.delegate"},
+		{"POST", "/api/v1/staking/{coinType}/undelegate", "staking.undelegate"},
+		{"GET", "/api/v1/staking/{coinType}/validators", "staking.validators"},
+		{"GET", "/api/v1/staking/{coinType}/interest", "staking.interest"},
+		{"POST", "/api/v1/staking/{coinType}/funding", "staking.funding"},
+		{"POST", "/api/v1/otc/symbols", "otc.symbols.set"},
+		{"GET", "/api/v1/otc/symbols", "otc.symbols.get"},
+		{"DELETE", "/api/v1/otc/symbol", "otc.symbol.delete"},
+		{"GET", "/api/v1/otc/orders", "otc.orders"},
+		{"GET", "/api/v1/otc/prices", "otc.prices"},
+		{"GET", "/api/v1/otc/order/{id}", "otc.order.get"},
+		{"POST", "/api/v1/otc/orders/{id}/price", "otc.price.feed"},
+		{"GET", "/api/v1/otc/price/custom/{customID}/close", "otc.price.close"},
+		{"GET", "/api/v1/otc/price/custom/{customID}/terminate", "otc.price.terminate"},
+		{"GET", "/api/v1/otc/price/custom/{customID}", "otc.price.get"},
+		{"GET", "/api/v1/otc/price/{id}/close", "otc.price.close"},
+		{"GET", "/api/v1/otc/price/{id}/terminate", "otc.price.terminate"},
+		{"GET", "/api/v1/otc/price/{id}", "otc.price.get"},
+		{"GET", "/api/v1/system/time", "system.time"},
+		{"GET", "/api/v1/market/{coinType}", "market"},
+	},
+	"company": {
+		{"GET", "/api/v1/funding/balances", "funding.wallets"},
+		{"POST", "/api/v1/funding/transfer", "funding.transfer"},
+		{"GET", "/api/v1/funding/records", "funding.records"},
+		{"POST", "/api/v1/app", "wallet.create"},
+		{"GET", "/api/v1/app/{id}/keys", "wallet.keys"},
+		{"GET", "/api/v1/app/{id}/info", "wallet.info"},
+		{"POST", "/api/v1/app/{id}/trade", "trade.create"},
+		{"GET", "/api/v1/app/{id}/trade/{tradeID}", "trade.get"},
+		{"PUT", "/api/v1/appKey/{key}", "walletKey.update"},
+		{"GET", "/api/v1/otc/customer/symbols", "otc.symbols.get"},
+	},
+	"kyc": {
+		{"GET", "/api/v1/generalSettings", "settings.get"},
+		{"POST", "/api/v1/file", "file.upload"},
+		{"GET", "/api/v1/file/{id}", "file.get"},
+		{"POST", "/api/v1/application", "application.create"},
+		{"GET", "/api/v1/application/identifier/{type}/{identifier}", "application.getByIdentifier"},
+		{"PATCH", "/api/v1/application/{id}", "application.update"},
+		{"GET", "/api/v1/application/{id}", "application.get"},
+		{"PUT", "/api/v1/application/{id}", "application.submit"},
+		{"GET", "/api/v1/application/{id}/jumio", "application.jumio.get"},
+		{"POST", "/api/v1/application/{id}/jumio", "application.jumio.post"},
+		{"PUT", "/api/v1/application/{id}/settings", "application.settings.update"},
+		{"GET", "/api/v1/application/{id}/histories", "application.histories"},
+		{"POST", "/api/v1/application/{id}/fiat", "fiat.create"},
+		{"GET", "/api/v1/application/{id}/fiats", "fiat.list"},
+		{"PUT", "/api/v1/fiat/{id}", "fiat.update"},
+		{"DELETE", "/api/v1/fiat/{id}", "fiat.delete"},
+	},
+}
+
+// resolveEndpoint returns the endpoint name of the request, such as
+// "app.withdraw", and the values of the path variables.
+func resolveEndpoint(kind, method, path string) (string, map[string]string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, e := range endpoints[kind] {
+		if e.method != method {
+			continue
+		}
+		if vars, ok := matchTemplate(e.template, segments); ok {
+			return kind + "." + e.name, vars
+		}
+	}
+	return kind + ".unknown", map[string]string{}
+}
+
+func matchTemplate(template string, segments []string) (map[string]string, bool) {
+	parts := strings.Split(strings.Trim(template, "/"), "/")
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+
+	vars := make(map[string]string)
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			vars[part[1:len(part)-1]] = segments[i]
+		} else if part != segments[i] {
+			return nil, false
+		}
+	}
+	return vars, true
+}
@@ -0,0 +1,28 @@
+package jadepoolsaas
+
+import "testing"
+
+func TestResolveEndpoint(t *testing.T) {
+	tests := []struct {
+		kind, method, path string
+		name, varName, val string
+	}{
+		{"app", "POST", "/api/v1/app/ETH/withdraw", "app.withdraw", "coinType", "ETH"},
+		{"app", "GET", "/api/v1/otc/price/custom/c1/close", "app.otc.price.close", "customID", "c1"},
+		{"app", "GET", "/api/v1/otc/price/p1", "app.otc.price.get", "id", "p1"},
+		{"company", "GET", "/api/v1/app/w1/keys", "company.wallet.keys", "id", "w1"},
+		{"kyc", "PATCH", "/api/v1/application/a1", "kyc.application.update", "id", "a1"},
+		{"kyc", "GET", "/api/v1/application/identifier/individual/u1", "kyc.application.getByIdentifier", "identifier", "u1"},
+		{"kyc", "GET", "/api/v1/unknown", "kyc.unknown", "", ""},
+	}
+
+	for _, test := range tests {
+		name, vars := resolveEndpoint(test.kind, test.method, test.path)
+		if name != test.name {
+			t.Errorf("%s %s name = %s; want %s", test.method, test.path, name, test.name)
+		}
+		if vars[test.varName] != test.val {
+			t.Errorf("%s %s %s = %s; want %s", test.method, test.path, test.varName, vars[test.varName], test.val)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	healthCheckPath    = "/api/v1/system/time"
+	healthCheckTimeout = 10 * time.Second
+)
+
+// Failover routes calls over an ordered list of server addresses, such as a
+// primary and a disaster-recovery endpoint. Calls go to the first healthy
+// address. GET calls fail over to the next address on network or server
+// errors, other calls only when the request provably never reached the server.
+type Failover struct {
+	addrs []string
+
+	mu         sync.RWMutex
+	httpClient *http.Client
+	clientSet  bool
+	healthy    []bool
+	stop       chan struct{}
+}
+
+// NewFailover creates a failover over addrs, all addresses start healthy.
+func NewFailover(addrs ...string) *Failover {
+	healthy := make([]bool, len(addrs))
+	for i := range healthy {
+		healthy[i] = true
+	}
+
+	return &Failover{
+		addrs:      addrs,
+		httpClient: http.DefaultClient,
+		healthy:    healthy,
+	}
+}
+
+// SetHTTPClient sends the health checks through c. Without it they share the
+// http client of the session using the failover, set by WithHTTPClient.
+func (f *Failover) SetHTTPClient(c *http.Client) {
+	f.mu.Lock()
+	f.httpClient = c
+	f.clientSet = true
+	f.mu.Unlock()
+}
+
+// shareClient sends the health checks through the client of a session,
+// unless a client was set.
+func (f *Failover) shareClient(c *http.Client) {
+	f.mu.Lock()
+	if !f.clientSet && c != nil {
+		f.httpClient = c
+	}
+	f.mu.Unlock()
+}
+
+// WithFailover routes the calls of the client with f, the addr of the client is ignored.
+func WithFailover(f *Failover) Option {
+	return func(s *session) {
+		s.failover = f
+	}
+}
+
+// Healthy returns the addresses currently considered healthy, in order.
+func (f *Failover) Healthy() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var ret []string
+	for i, addr := range f.addrs {
+		if f.healthy[i] {
+			ret = append(ret, addr)
+		}
+	}
+	return ret
+}
+
+// Check requests the system time of every address and updates their health.
+// An address is healthy if it answers without a server error.
+func (f *Failover) Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i, addr := range f.addrs {
+		wg.Add(1)
+		go func(i int, addr string) {
+			defer wg.Done()
+			f.setHealthy(i, f.check(ctx, addr))
+		}(i, addr)
+	}
+	wg.Wait()
+}
+
+// Start checks the addresses every interval until Stop is called.
+func (f *Failover) Start(interval time.Duration) {
+	f.mu.Lock()
+	if f.stop != nil {
+		f.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	f.stop = stop
+	f.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			f.Check(context.Background())
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic checks.
+func (f *Failover) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stop != nil {
+		close(f.stop)
+		f.stop = nil
+	}
+}
+
+func (f *Failover) check(ctx context.Context, addr string) bool {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, "GET", addr+healthCheckPath, nil)
+	if err != nil {
+		return false
+	}
+	f.mu.RLock()
+	client := f.httpClient
+	f.mu.RUnlock()
+	response, err := client.Do(request)
+	if err != nil {
+		return false
+	}
+	response.Body.Close()
+	return response.StatusCode < 500
+}
+
+func (f *Failover) do(call *Call, send func(addr string, call *Call) error) error {
+	var err error
+	for i, index := range f.candidates() {
+		if i > 0 {
+			if call.body != nil || call.Context().Err() != nil {
+				break
+			}
+			call.Retries++
+		}
+
+		err = send(f.addrs[index], call)
+		if err == nil {
+			return nil
+		}
+
+		switch {
+		case neverReached(err):
+			f.setHealthy(index, false)
+		case call.Method == "GET" && (call.StatusCode == 0 || call.StatusCode >= 500):
+			f.setHealthy(index, false)
+		default:
+			return err
+		}
+	}
+	return err
+}
+
+// candidates returns the indexes of healthy addresses followed by the unhealthy ones.
+func (f *Failover) candidates() []int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	ret := make([]int, 0, len(f.addrs))
+	for i := range f.addrs {
+		if f.healthy[i] {
+			ret = append(ret, i)
+		}
+	}
+	for i := range f.addrs {
+		if !f.healthy[i] {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+func (f *Failover) setHealthy(index int, healthy bool) {
+	f.mu.Lock()
+	f.healthy[index] = healthy
+	f.mu.Unlock()
+}
+
+// neverReached reports whether the request failed before a connection to the server was made.
+func neverReached(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,89 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFailover(t *testing.T) {
+	response := map[string]interface{}{}
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	downAddr := down.URL
+	down.Close()
+
+	var unavailableHits int
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		unavailableHits++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	var healthyHits int
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthyHits++
+		_, err := writeSuccessResponse(w, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer healthy.Close()
+
+	f := NewFailover(downAddr, healthy.URL)
+	app := NewApp(TestAppKey, TestAppSecret, WithFailover(f))
+	if _, err := app.Withdraw("1", "ETH", "0x7C3A4d3ff2b92CFDD2eD1a105d5bAc8fAF4008aE", "0.01"); err != nil {
+		t.Fatal(err)
+	}
+	if healthyHits != 1 {
+		t.Errorf("healthy hits = %d; want 1", healthyHits)
+	}
+	if got := f.Healthy(); len(got) != 1 || got[0] != healthy.URL {
+		t.Errorf("healthy = %v; want [%s]", got, healthy.URL)
+	}
+
+	f = NewFailover(unavailable.URL, healthy.URL)
+	app = NewApp(TestAppKey, TestAppSecret, WithFailover(f))
+	if _, err := app.GetBalance("ETH"); err != nil {
+		t.Fatal(err)
+	}
+
+	f = NewFailover(unavailable.URL, healthy.URL)
+	app = NewApp(TestAppKey, TestAppSecret, WithFailover(f))
+	healthyHits = 0
+	if _, err := app.Withdraw("2", "ETH", "0x7C3A4d3ff2b92CFDD2eD1a105d5bAc8fAF4008aE", "0.01"); err == nil {
+		t.Fatal("want http error without failing over a request that reached the server")
+	}
+	if healthyHits != 0 {
+		t.Errorf("healthy hits = %d; want 0", healthyHits)
+	}
+
+	f = NewFailover(downAddr, unavailable.URL, healthy.URL)
+	f.Check(context.Background())
+	if got := f.Healthy(); len(got) != 1 || got[0] != healthy.URL {
+		t.Errorf("healthy = %v; want [%s]", got, healthy.URL)
+	}
+}
+
+func TestFailoverSharesHTTPClient(t *testing.T) {
+	tls := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := writeSuccessResponse(w, map[string]interface{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer tls.Close()
+
+	f := NewFailover(tls.URL)
+	f.Check(context.Background())
+	if got := f.Healthy(); len(got) != 0 {
+		t.Errorf("healthy = %v; want none without the tls client", got)
+	}
+
+	NewApp(TestAppKey, TestAppSecret, WithFailover(f), WithHTTPClient(tls.Client()))
+	f.Check(context.Background())
+	if got := f.Healthy(); len(got) != 1 {
+		t.Errorf("healthy = %v; want [%s] with the client of the session", got, tls.URL)
+	}
+}
@@ -0,0 +1,155 @@
+package jadepoolsaas
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrDigestUnavailable is returned by downloads requiring a digest when
+// neither the server nor the caller provides one.
+var ErrDigestUnavailable = errors.New("file digest is unavailable")
+
+// IntegrityError reports transferred file content not matching its digest.
+type IntegrityError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("file integrity check failed: sha256 %s, want %s", e.Actual, e.Expected)
+}
+
+func verifyDigest(expected, actual string) error {
+	if expected == "" || strings.EqualFold(expected, actual) {
+		return nil
+	}
+	return &IntegrityError{Expected: strings.ToLower(expected), Actual: actual}
+}
+
+// fileDigest returns the hex sha256 digest of the file.
+func fileDigest(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// digestReader returns the hex sha256 digest of r and a reader of the same
+// content. Seekable readers are rewound, others are spooled to a temporary
+// file rather than memory. cleanup must be called once the reader is consumed.
+func digestReader(r io.Reader) (digest string, content io.Reader, cleanup func(), err error) {
+	h := sha256.New()
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if _, err = io.Copy(h, seeker); err != nil {
+			return "", nil, nil, err
+		}
+		if _, err = seeker.Seek(start, io.SeekStart); err != nil {
+			return "", nil, nil, err
+		}
+		return hex.EncodeToString(h.Sum(nil)), seeker, func() {}, nil
+	}
+
+	f, err := ioutil.TempFile("", "jadepool-upload")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	cleanup = func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	if _, err = io.Copy(io.MultiWriter(f, h), r); err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), f, cleanup, nil
+}
+
+// headerDigest returns the hex sha256 digest announced by the response headers,
+// from X-Content-SHA256, Content-Digest or Digest.
+func headerDigest(header http.Header) string {
+	if digest := header.Get("X-Content-SHA256"); digest != "" {
+		return strings.ToLower(digest)
+	}
+	if digest := parseDigestHeader(header.Get("Content-Digest"), "sha-256=:", ":"); digest != "" {
+		return digest
+	}
+	return parseDigestHeader(header.Get("Digest"), "sha-256=", "")
+}
+
+func parseDigestHeader(value, prefix, suffix string) string {
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) < len(prefix) || !strings.EqualFold(item[:len(prefix)], prefix) {
+			continue
+		}
+		buf, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(item[len(prefix):], suffix))
+		if err != nil {
+			return ""
+		}
+		return hex.EncodeToString(buf)
+	}
+	return ""
+}
+
+// hashingWriter computes the sha256 digest of the content written through it.
+type hashingWriter struct {
+	io.Writer
+	hash hash.Hash
+}
+
+func newHashingWriter(w io.Writer) *hashingWriter {
+	h := sha256.New()
+	return &hashingWriter{Writer: io.MultiWriter(w, h), hash: h}
+}
+
+func (w *hashingWriter) digest() string {
+	return hex.EncodeToString(w.hash.Sum(nil))
+}
+
+// writeVerifiedFile writes body to filePath, the file is only created if its
+// content matches the expected digest.
+func writeVerifiedFile(filePath string, body io.Reader, expected string) error {
+	f, err := ioutil.TempFile(filepath.Dir(filePath), ".jadepool-download")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	w := newHashingWriter(f)
+	_, err = io.Copy(w, body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = verifyDigest(expected, w.digest()); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filePath)
+}
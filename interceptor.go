@@ -0,0 +1,97 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/imroc/req"
+)
+
+// Call describes a single api call passing through the interceptor chain.
+type Call struct {
+	Method string
+	Path   string
+	// Endpoint is the stable name of the api, such as "app.withdraw".
+	Endpoint string
+	// Params are the request params, already signed. Modifying them
+	// invalidates the signature.
+	Params map[string]interface{}
+	Header http.Header
+
+	// StatusCode, ResponseHeader and Result are filled once the response is received.
+	StatusCode     int
+	ResponseHeader http.Header
+	Result         *Result
+	// Retries counts the extra attempts made to complete the call.
+	Retries int
+
+	ctx  context.Context
+	vars map[string]string
+	body interface{}
+	raw  bool
+	resp *req.Resp
+}
+
+// Context returns the context the call is bound to.
+func (c *Call) Context() context.Context {
+	return c.ctx
+}
+
+// SetContext binds the call to ctx, interceptors use it to pass values down the chain.
+func (c *Call) SetContext(ctx context.Context) {
+	c.ctx = ctx
+}
+
+// RoundTrip performs an api call.
+type RoundTrip func(call *Call) error
+
+// Interceptor wraps a RoundTrip with additional behavior, it should call
+// next to continue the chain.
+type Interceptor func(next RoundTrip) RoundTrip
+
+// WithInterceptors appends interceptors to the chain, the first one is the outermost.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(s *session) {
+		s.interceptors = append(s.interceptors, interceptors...)
+	}
+}
+
+// UserAgent sets the User-Agent header of every request.
+func UserAgent(userAgent string) Interceptor {
+	return func(next RoundTrip) RoundTrip {
+		return func(call *Call) error {
+			call.Header.Set("User-Agent", userAgent)
+			return next(call)
+		}
+	}
+}
+
+// RequestID sets a random request id in the specified header unless the
+// header is already present.
+func RequestID(headerName string) Interceptor {
+	return func(next RoundTrip) RoundTrip {
+		return func(call *Call) error {
+			if call.Header.Get(headerName) == "" {
+				id := make([]byte, 16)
+				rand.Read(id)
+				call.Header.Set(headerName, hex.EncodeToString(id))
+			}
+			return next(call)
+		}
+	}
+}
+
+// Timing reports the duration and error of every call.
+func Timing(report func(call *Call, duration time.Duration, err error)) Interceptor {
+	return func(next RoundTrip) RoundTrip {
+		return func(call *Call) error {
+			start := time.Now()
+			err := next(call)
+			report(call, time.Since(start), err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package jadepoolsaas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInterceptors(t *testing.T) {
+	response := map[string]interface{}{
+		"coinType": "ETH",
+	}
+
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "custody-test" {
+			t.Errorf("User-Agent = %s; want custody-test", r.Header.Get("User-Agent"))
+		}
+		if r.Header.Get("X-Request-ID") == "" {
+			t.Error("X-Request-ID is empty")
+		}
+
+		_, err := writeSuccessResponse(w, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	var order []string
+	trace := func(name string) Interceptor {
+		return func(next RoundTrip) RoundTrip {
+			return func(call *Call) error {
+				order = append(order, name)
+				if _, ok := call.Params["sign"]; !ok {
+					t.Error("params are not signed")
+				}
+				return next(call)
+			}
+		}
+	}
+
+	var timed *Call
+	app := NewAppWithAddr(ts.URL, TestAppKey, TestAppSecret, WithInterceptors(
+		trace("outer"),
+		trace("inner"),
+		UserAgent("custody-test"),
+		RequestID("X-Request-ID"),
+		Timing(func(call *Call, duration time.Duration, err error) {
+			if err != nil {
+				t.Error(err)
+			}
+			timed = call
+		}),
+	))
+	_, err := app.GetBalance("ETH")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Errorf("order = %v; want [outer inner]", order)
+	}
+	if timed == nil || timed.Path != "/api/v1/app/balance/ETH" || timed.Method != "GET" {
+		t.Fatalf("timed call = %+v; want GET /api/v1/app/balance/ETH", timed)
+	}
+	if timed.StatusCode != 200 || timed.Result == nil || timed.Result.Data["coinType"] != "ETH" {
+		t.Errorf("timed call = %+v; want decoded result", timed)
+	}
+}
@@ -2,20 +2,29 @@ package jadepoolsaas
 
 import (
 	"bytes"
+	"context"
+
 	"github.com/imroc/req"
 )
 
 // NewKYCWithAddr creates a new kyc instance with server addr, key and secret.
-func NewKYCWithAddr(addr, appKey, appSecret string) *KYC {
+func NewKYCWithAddr(addr, appKey, appSecret string, opts ...Option) *KYC {
 	a := &KYC{
 		Addr:   addr,
 		Key:    appKey,
 		Secret: appSecret,
 	}
-	a.session = &session{client: a}
+	a.session = newSession(a, opts)
 	return a
 }
 
+// WithContext returns a shallow copy of the kyc instance whose requests are bound to ctx.
+func (k *KYC) WithContext(ctx context.Context) *KYC {
+	copied := *k
+	copied.session = k.session.withContext(&copied, ctx)
+	return &copied
+}
+
 // GeneralSettingsGet get the general settings.
 func (k *KYC) GeneralSettingsGet() (*Result, error) {
 	return k.session.get("/api/v1/generalSettings")
@@ -34,11 +43,16 @@ func (k *KYC) FileUpload2(applicationID, fileName string, file *bytes.Reader) (*
 }
 
 // FileGet get file.
+//
+// Deprecated: the content is not verified if the server sends no digest, use
+// DownloadFile with RequireDigest instead.
 func (k *KYC) FileGet(fileID, filePath string) (*Result, error) {
 	return k.session.getFile("/api/v1/file/"+fileID, filePath)
 }
 
 // FileGet2 get file.
+//
+// Deprecated: the content is not verified, use DownloadFile instead.
 func (k *KYC) FileGet2(fileID, applicationID string) (*req.Resp, error) {
 	return k.session.getFile2("/api/v1/file/"+fileID, map[string]interface{}{
 		"applicationID": applicationID,
@@ -137,6 +151,10 @@ type KYC struct {
 	session *session
 }
 
+func (k *KYC) getKind() string {
+	return "kyc"
+}
+
 func (k *KYC) getKey() string {
 	return k.Key
 }
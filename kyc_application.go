@@ -0,0 +1,216 @@
+package jadepoolsaas
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Application types accepted by ApplicationCreate.
+const (
+	ApplicationTypeIndividual = "individual"
+	ApplicationTypeCorporate  = "corporate"
+)
+
+// Address is a postal address.
+type Address struct {
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	State      string `json:"state,omitempty"`
+	PostalCode string `json:"postalCode,omitempty"`
+	// Country is an ISO 3166-1 alpha-2 code.
+	Country string `json:"country"`
+}
+
+// Document references an uploaded kyc file.
+type Document struct {
+	// Type is the kind of document, such as passport, idCard or proofOfAddress.
+	Type   string `json:"type"`
+	FileID string `json:"fileID"`
+}
+
+// Person is the identity of a natural person.
+type Person struct {
+	FirstName  string `json:"firstName"`
+	MiddleName string `json:"middleName,omitempty"`
+	LastName   string `json:"lastName"`
+	// DateOfBirth is formatted as YYYY-MM-DD.
+	DateOfBirth string `json:"dateOfBirth"`
+	// Nationality is an ISO 3166-1 alpha-2 code.
+	Nationality string `json:"nationality"`
+	IDNumber    string `json:"idNumber,omitempty"`
+}
+
+// IndividualApplication is the content of an individual applicant.
+type IndividualApplication struct {
+	Person
+	Email      string     `json:"email,omitempty"`
+	Phone      string     `json:"phone,omitempty"`
+	Occupation string     `json:"occupation,omitempty"`
+	Address    Address    `json:"address"`
+	Documents  []Document `json:"documents,omitempty"`
+}
+
+// UBO is an ultimate beneficial owner of a corporate applicant.
+type UBO struct {
+	Person
+	// Ownership is the percentage of the company owned.
+	Ownership float64 `json:"ownership"`
+	Address   Address `json:"address"`
+}
+
+// CorporateApplication is the content of a corporate applicant.
+type CorporateApplication struct {
+	CompanyName        string `json:"companyName"`
+	RegistrationNumber string `json:"registrationNumber"`
+	// IncorporationDate is formatted as YYYY-MM-DD.
+	IncorporationDate string `json:"incorporationDate"`
+	// IncorporationCountry is an ISO 3166-1 alpha-2 code.
+	IncorporationCountry string     `json:"incorporationCountry"`
+	RegisteredAddress    Address    `json:"registeredAddress"`
+	BusinessAddress      *Address   `json:"businessAddress,omitempty"`
+	Email                string     `json:"email,omitempty"`
+	Directors            []Person   `json:"directors"`
+	UBOs                 []UBO      `json:"ubos"`
+	Documents            []Document `json:"documents,omitempty"`
+}
+
+// ApplicationContent is the typed content of an application.
+type ApplicationContent interface {
+	// Validate returns ValidationErrors listing every invalid field.
+	Validate() error
+}
+
+// Validate checks the fields of the individual application.
+func (a *IndividualApplication) Validate() error {
+	v := newValidator()
+	a.Person.validate(v)
+	v.email("email", a.Email)
+	a.Address.validate(v.nested("address"))
+	validateDocuments(v, a.Documents)
+	return v.err()
+}
+
+// Validate checks the fields of the corporate application.
+func (a *CorporateApplication) Validate() error {
+	v := newValidator()
+	v.required("companyName", a.CompanyName)
+	v.required("registrationNumber", a.RegistrationNumber)
+	v.pastDate("incorporationDate", a.IncorporationDate)
+	v.country("incorporationCountry", a.IncorporationCountry)
+	a.RegisteredAddress.validate(v.nested("registeredAddress"))
+	if a.BusinessAddress != nil {
+		a.BusinessAddress.validate(v.nested("businessAddress"))
+	}
+	v.email("email", a.Email)
+
+	if len(a.Directors) == 0 {
+		v.add("directors", "at least one director is required")
+	}
+	for i := range a.Directors {
+		a.Directors[i].validate(v.nested("directors[" + strconv.Itoa(i) + "]"))
+	}
+
+	var ownership float64
+	for i, ubo := range a.UBOs {
+		uv := v.nested("ubos[" + strconv.Itoa(i) + "]")
+		ubo.Person.validate(uv)
+		ubo.Address.validate(uv.nested("address"))
+		if ubo.Ownership <= 0 || ubo.Ownership > 100 {
+			uv.add("ownership", "must be greater than 0 and at most 100")
+		}
+		ownership += ubo.Ownership
+	}
+	if ownership > 100 {
+		v.add("ubos", "total ownership must not exceed 100")
+	}
+
+	validateDocuments(v, a.Documents)
+	return v.err()
+}
+
+func (p *Person) validate(v *validator) {
+	v.required("firstName", p.FirstName)
+	v.required("lastName", p.LastName)
+	v.pastDate("dateOfBirth", p.DateOfBirth)
+	v.country("nationality", p.Nationality)
+}
+
+func (a *Address) validate(v *validator) {
+	v.required("line1", a.Line1)
+	v.required("city", a.City)
+	v.country("country", a.Country)
+}
+
+func validateDocuments(v *validator, documents []Document) {
+	for i, doc := range documents {
+		dv := v.nested("documents[" + strconv.Itoa(i) + "]")
+		dv.required("type", doc.Type)
+		dv.required("fileID", doc.FileID)
+	}
+}
+
+// ApplicationChanges returns the top-level fields of next which differ from
+// prev, with their new values. If prev is nil all fields of next are returned.
+func ApplicationChanges(prev, next ApplicationContent) (map[string]interface{}, error) {
+	nextFields, err := contentFields(next)
+	if err != nil {
+		return nil, err
+	}
+	if prev == nil || reflect.ValueOf(prev).IsNil() {
+		return nextFields, nil
+	}
+	if reflect.TypeOf(prev) != reflect.TypeOf(next) {
+		return nil, fmt.Errorf("cannot compare %T with %T", prev, next)
+	}
+
+	prevFields, err := contentFields(prev)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]interface{})
+	for key, val := range nextFields {
+		if !reflect.DeepEqual(prevFields[key], val) {
+			changes[key] = val
+		}
+	}
+	for key := range prevFields {
+		if _, ok := nextFields[key]; !ok {
+			changes[key] = nil
+		}
+	}
+	return changes, nil
+}
+
+// ApplicationSave validates next and patches the fields changed since prev
+// with ApplicationUpdate2, prev may be nil to send every field. If nothing
+// changed no request is sent and the result is nil.
+func (k *KYC) ApplicationSave(applicationID string, prev, next ApplicationContent) (*Result, error) {
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+
+	changes, err := ApplicationChanges(prev, next)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return k.ApplicationUpdate2(applicationID, changes)
+}
+
+func contentFields(content ApplicationContent) (map[string]interface{}, error) {
+	buf, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	if err = json.Unmarshal(buf, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
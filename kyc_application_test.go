@@ -0,0 +1,121 @@
+package jadepoolsaas
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func testIndividualApplication() *IndividualApplication {
+	return &IndividualApplication{
+		Person: Person{
+			FirstName:   "Alice",
+			LastName:    "Smith",
+			DateOfBirth: "1990-01-31",
+			Nationality: "GB",
+		},
+		Email: "alice@example.com",
+		Address: Address{
+			Line1:   "1 High Street",
+			City:    "London",
+			Country: "GB",
+		},
+	}
+}
+
+func TestApplicationValidate(t *testing.T) {
+	if err := testIndividualApplication().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	corporate := &CorporateApplication{
+		CompanyName:          "Acme Ltd",
+		IncorporationDate:    "2090-01-01",
+		IncorporationCountry: "gb",
+		RegisteredAddress:    Address{Line1: "1 High Street", City: "London", Country: "GB"},
+		UBOs: []UBO{
+			{Person: testIndividualApplication().Person, Ownership: 80, Address: Address{Line1: "1", City: "London", Country: "GB"}},
+			{Person: Person{FirstName: "Bob", DateOfBirth: "1980-02-30", Nationality: "GB"}, Ownership: 30},
+		},
+	}
+	err := corporate.Validate()
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("err = %v; want ValidationErrors", err)
+	}
+	fields := make(map[string]bool)
+	for _, fieldErr := range errs {
+		fields[fieldErr.Field] = true
+	}
+	for _, field := range []string{
+		"registrationNumber",
+		"incorporationDate",
+		"incorporationCountry",
+		"directors",
+		"ubos[1].lastName",
+		"ubos[1].dateOfBirth",
+		"ubos[1].address.city",
+		"ubos",
+	} {
+		if !fields[field] {
+			t.Errorf("errors = %v; want error for %s", errs, field)
+		}
+	}
+	if fields["ubos[0].firstName"] {
+		t.Errorf("errors = %v; want ubos[0] valid", errs)
+	}
+}
+
+func TestApplicationSave(t *testing.T) {
+	response := map[string]interface{}{}
+
+	var body map[string]interface{}
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" {
+			t.Errorf("method = %s; want PATCH", r.Method)
+		}
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+
+		_, err := writeSuccessResponse(w, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret)
+	prev := testIndividualApplication()
+	next := testIndividualApplication()
+	next.Address.City = "Manchester"
+	next.Occupation = "engineer"
+
+	if _, err := kyc.ApplicationSave("a1", prev, next); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"address", "occupation"} {
+		if _, ok := body[key]; !ok {
+			t.Errorf("body = %v; want %s", body, key)
+		}
+	}
+	for _, key := range []string{"firstName", "email"} {
+		if _, ok := body[key]; ok {
+			t.Errorf("body = %v; want unchanged %s omitted", body, key)
+		}
+	}
+
+	body = nil
+	result, err := kyc.ApplicationSave("a1", next, next)
+	if err != nil || result != nil || body != nil {
+		t.Errorf("save unchanged = %v, %v; want no request", result, err)
+	}
+
+	next.Email = "alice"
+	if _, err = kyc.ApplicationSave("a1", prev, next); err == nil {
+		t.Error("want validation error")
+	}
+}
@@ -0,0 +1,278 @@
+package jadepoolsaas
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Default limits of bulk uploads.
+const (
+	DefaultUploadMaxSize     = 10 << 20
+	DefaultUploadConcurrency = 4
+)
+
+// DefaultUploadTypes are the content types uploaded by default.
+var DefaultUploadTypes = []string{"application/pdf", "image/jpeg", "image/png"}
+
+// BulkUploadOptions configures a bulk upload, zero values use the defaults.
+type BulkUploadOptions struct {
+	// MaxSize is the maximum size of a file in bytes.
+	MaxSize int64
+	// AllowedTypes are the accepted content types.
+	AllowedTypes []string
+	// Concurrency is the maximum number of uploads in flight.
+	Concurrency int
+	// Field returns the application field the file is attached to, an empty
+	// field does not attach it. Files of the documents field are attached as
+	// Document, files of other fields by their id, or a list of ids if a field
+	// has several files. Nil attaches every file to documents.
+	Field func(name, contentType string) string
+	// DocumentType returns the type of a file attached to documents, nil uses
+	// the file name without extension.
+	DocumentType func(name, contentType string) string
+	// ReplaceDocuments replaces the documents of the application with the
+	// uploaded ones, by default they are added to the current documents.
+	ReplaceDocuments bool
+	// Image preprocesses jpeg and png files with PreprocessImage before the
+	// size limit is checked, nil uploads them unchanged. Files over its
+	// MaxInputSize are not read.
+	Image *ImageOptions
+}
+
+// BulkUploadResult is the result of uploading a file.
+type BulkUploadResult struct {
+	Path        string `json:"path"`
+	ContentType string `json:"contentType,omitempty"`
+	Size        int64  `json:"size"`
+	FileID      string `json:"fileID,omitempty"`
+	Field       string `json:"field,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Err         error  `json:"-"`
+}
+
+// BulkUploadReport lists the result of every file in order.
+type BulkUploadReport struct {
+	Results   []*BulkUploadResult `json:"results"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+}
+
+// UploadDirectory uploads the regular files of dir, hidden files and sub
+// directories are skipped. See UploadFiles.
+func (k *KYC) UploadDirectory(ctx context.Context, applicationID, dir string, opts BulkUploadOptions) (*BulkUploadReport, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(paths)
+	return k.UploadFiles(ctx, applicationID, paths, opts)
+}
+
+// UploadFiles uploads the files concurrently with FileUpload2 and attaches
+// the ids of the uploaded files to the application with ApplicationUpdate2,
+// replacing the previous values of the fields. Documents are added to the
+// current documents of the application unless ReplaceDocuments. Files over the size limit or
+// of a type not allowed fail without being uploaded. A failed file does not
+// stop the others, the error is only returned if the files cannot be
+// attached.
+func (k *KYC) UploadFiles(ctx context.Context, applicationID string, paths []string, opts BulkUploadOptions) (*BulkUploadReport, error) {
+	if len(applicationID) == 0 {
+		return nil, errors.New("applicationID is empty")
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultUploadMaxSize
+	}
+	if len(opts.AllowedTypes) == 0 {
+		opts.AllowedTypes = DefaultUploadTypes
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultUploadConcurrency
+	}
+
+	kyc := k.WithContext(ctx)
+	report := &BulkUploadReport{Results: make([]*BulkUploadResult, len(paths))}
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		result := &BulkUploadResult{Path: path}
+		report.Results[i] = result
+
+		select {
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			result.Err = kyc.uploadBulkFile(applicationID, result, &opts)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Results {
+		if result.Err != nil {
+			result.Error = result.Err.Error()
+			report.Failed++
+		} else {
+			report.Succeeded++
+		}
+	}
+
+	fields := bulkUploadFields(report.Results, &opts)
+	if len(fields) == 0 {
+		return report, nil
+	}
+	if documents, ok := fields["documents"].([]interface{}); ok && !opts.ReplaceDocuments {
+		ret, err := checkResult(kyc.ApplicationGet(applicationID, false))
+		if err != nil {
+			return report, fmt.Errorf("get documents failed: %v", err)
+		}
+		fields["documents"] = mergeDocuments(dataList(ret.Data, "documents"), documents)
+	}
+	if _, err := checkResult(kyc.ApplicationUpdate2(applicationID, fields)); err != nil {
+		return report, fmt.Errorf("attach files failed: %v", err)
+	}
+	return report, nil
+}
+
+func (k *KYC) uploadBulkFile(applicationID string, result *BulkUploadResult, opts *BulkUploadOptions) error {
+	info, err := os.Stat(result.Path)
+	if err != nil {
+		return err
+	}
+	result.Size = info.Size()
+	if opts.Image == nil && result.Size > opts.MaxSize {
+		return fmt.Errorf("file size %d exceeds the limit %d", result.Size, opts.MaxSize)
+	}
+	if opts.Image != nil && result.Size > opts.Image.maxInputSize() {
+		return fmt.Errorf("file size %d exceeds the limit %d", result.Size, opts.Image.maxInputSize())
+	}
+
+	content, err := ioutil.ReadFile(result.Path)
+	if err != nil {
+		return err
+	}
+	result.ContentType = detectContentType(result.Path, content)
+	if !containsString(opts.AllowedTypes, result.ContentType) {
+		return fmt.Errorf("content type %s is not allowed", result.ContentType)
+	}
+	if opts.Image != nil && (result.ContentType == "image/jpeg" || result.ContentType == "image/png") {
+		if content, _, err = PreprocessImage(bytes.NewReader(content), *opts.Image); err != nil {
+			return err
+		}
+		result.Size = int64(len(content))
+	}
+	if result.Size > opts.MaxSize {
+		return fmt.Errorf("file size %d exceeds the limit %d", result.Size, opts.MaxSize)
+	}
+
+	ret, err := checkResult(k.FileUpload2(applicationID, filepath.Base(result.Path), bytes.NewReader(content)))
+	if err != nil {
+		return err
+	}
+	result.FileID = stringField(ret.Data, "id", "fileID")
+	if result.FileID == "" {
+		return errors.New("file id is missing in the result")
+	}
+	return nil
+}
+
+// mergeDocuments appends the added documents to the current ones, skipping
+// the files already attached.
+func mergeDocuments(current, added []interface{}) []interface{} {
+	documents := make([]interface{}, 0, len(current)+len(added))
+	attached := make(map[string]bool)
+	for _, item := range current {
+		doc, _ := item.(map[string]interface{})
+		if fileID := stringField(doc, "fileID"); fileID != "" {
+			attached[fileID] = true
+		}
+		documents = append(documents, item)
+	}
+	for _, item := range added {
+		doc, _ := item.(map[string]interface{})
+		if !attached[stringField(doc, "fileID")] {
+			documents = append(documents, item)
+		}
+	}
+	return documents
+}
+
+// detectContentType sniffs the content, falling back to the file extension
+// when the content is not recognized.
+func detectContentType(path string, content []byte) string {
+	contentType := http.DetectContentType(content)
+	if contentType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(filepath.Ext(path)); byExt != "" {
+			contentType = byExt
+		}
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return contentType
+}
+
+func bulkUploadFields(results []*BulkUploadResult, opts *BulkUploadOptions) map[string]interface{} {
+	ids := make(map[string][]string)
+	fields := make(map[string]interface{})
+	var documents []interface{}
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		name := filepath.Base(result.Path)
+		field := "documents"
+		if opts.Field != nil {
+			field = opts.Field(name, result.ContentType)
+		}
+		result.Field = field
+
+		switch field {
+		case "":
+		case "documents":
+			docType := strings.TrimSuffix(name, filepath.Ext(name))
+			if opts.DocumentType != nil {
+				docType = opts.DocumentType(name, result.ContentType)
+			}
+			documents = append(documents, map[string]interface{}{"type": docType, "fileID": result.FileID})
+		default:
+			ids[field] = append(ids[field], result.FileID)
+		}
+	}
+
+	if len(documents) > 0 {
+		fields["documents"] = documents
+	}
+	for field, fieldIDs := range ids {
+		if len(fieldIDs) == 1 {
+			fields[field] = fieldIDs[0]
+		} else {
+			fields[field] = fieldIDs
+		}
+	}
+	return fields
+}
@@ -0,0 +1,117 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUploadDirectory(t *testing.T) {
+	var inFlight, maxInFlight int32
+	var mu sync.Mutex
+	var fields map[string]interface{}
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		data := map[string]interface{}{}
+		switch r.Method {
+		case "POST":
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			mu.Lock()
+			if n > maxInFlight {
+				maxInFlight = n
+			}
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+
+			_, header, err := r.FormFile("file")
+			if err != nil {
+				t.Error(err)
+			}
+			data["id"] = "id-" + header.Filename
+		case "GET":
+			data["documents"] = []interface{}{
+				map[string]interface{}{"type": "passport", "fileID": "onboarded"},
+			}
+		case "PATCH":
+			json.NewDecoder(r.Body).Decode(&fields)
+		}
+
+		_, err := writeSuccessResponse(w, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "bulk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pdf := []byte("%PDF-1.4\n")
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	for name, content := range map[string][]byte{
+		"passport.pdf": pdf,
+		"proof.pdf":    pdf,
+		"selfie.png":   png,
+		"license.png":  png,
+		"notes.txt":    []byte("hello"),
+		"large.pdf":    append(pdf, make([]byte, 100)...),
+		".hidden.pdf":  pdf,
+	} {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret)
+	report, err := kyc.UploadDirectory(context.Background(), "a1", dir, BulkUploadOptions{
+		MaxSize:     50,
+		Concurrency: 2,
+		Field: func(name, contentType string) string {
+			if name == "selfie.png" {
+				return "selfie"
+			}
+			return "documents"
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Succeeded != 4 || report.Failed != 2 {
+		t.Errorf("report = %d succeeded, %d failed; want 4, 2", report.Succeeded, report.Failed)
+	}
+	for _, result := range report.Results {
+		name := filepath.Base(result.Path)
+		failed := name == "notes.txt" || name == "large.pdf"
+		if (result.Error != "") != failed {
+			t.Errorf("result of %s = %+v; want failed %v", name, result, failed)
+		}
+	}
+	if maxInFlight > 2 {
+		t.Errorf("max in flight = %d; want at most 2", maxInFlight)
+	}
+
+	if fields["selfie"] != "id-selfie.png" {
+		t.Errorf("selfie = %v; want id-selfie.png", fields["selfie"])
+	}
+	documents, _ := fields["documents"].([]interface{})
+	if len(documents) != 4 {
+		t.Fatalf("documents = %v; want the current one and 3 uploaded", fields["documents"])
+	}
+	if doc := documents[0].(map[string]interface{}); doc["fileID"] != "onboarded" {
+		t.Errorf("documents[0] = %v; want the current document kept", doc)
+	}
+	if doc := documents[1].(map[string]interface{}); doc["type"] != "license" || doc["fileID"] != "id-license.png" {
+		t.Errorf("documents[1] = %v; want license", doc)
+	}
+}
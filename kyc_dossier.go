@@ -0,0 +1,227 @@
+package jadepoolsaas
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DossierEntry is a file of a dossier archive.
+type DossierEntry struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	FileID      string `json:"fileID,omitempty"`
+	Name        string `json:"name,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// DossierManifest describes the content of a dossier archive, it is written
+// as manifest.json, the last file of the archive.
+type DossierManifest struct {
+	ApplicationID string         `json:"applicationID"`
+	GeneratedAt   time.Time      `json:"generatedAt"`
+	Entries       []DossierEntry `json:"entries"`
+}
+
+// ExportDossier writes a zip archive of everything known about the
+// application: application.json from ApplicationGet with expand, fiats.json,
+// histories.json with the field changes, every referenced file under
+// documents/, summary.txt and manifest.json listing the SHA-256 of every
+// other file. Files are verified and spooled to disk before being archived.
+func (k *KYC) ExportDossier(ctx context.Context, applicationID string, w io.Writer) (*DossierManifest, error) {
+	if len(applicationID) == 0 {
+		return nil, errors.New("applicationID is empty")
+	}
+	kyc := k.WithContext(ctx)
+
+	app, err := checkResult(kyc.ApplicationGet(applicationID, true))
+	if err != nil {
+		return nil, err
+	}
+	fiats, err := checkResult(kyc.FiatsGet(applicationID))
+	if err != nil {
+		return nil, err
+	}
+	records, err := kyc.ApplicationHistoryRecords(applicationID)
+	if err != nil {
+		return nil, err
+	}
+	changes := DiffHistories(records)
+
+	d := &dossier{
+		zip:      zip.NewWriter(w),
+		manifest: &DossierManifest{ApplicationID: applicationID, GeneratedAt: time.Now().UTC()},
+	}
+	for _, entry := range []struct {
+		name string
+		v    interface{}
+	}{
+		{"application.json", app.Data},
+		{"fiats.json", fiatList(fiats.Data)},
+		{"histories.json", changes},
+	} {
+		if err = d.addJSON(entry.name, entry.v); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, fileID := range referencedFiles(app.Data) {
+		if err = d.addDocument(ctx, kyc, applicationID, fileID); err != nil {
+			return nil, fmt.Errorf("export file %s failed: %v", fileID, err)
+		}
+	}
+
+	var summary bytes.Buffer
+	writeDossierSummary(&summary, d.manifest, app.Data, len(fiatList(fiats.Data)), changes)
+	if err = d.add("summary.txt", &DossierEntry{ContentType: "text/plain"}, &summary); err != nil {
+		return nil, err
+	}
+
+	buf, err := json.MarshalIndent(d.manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	f, err := d.zip.Create("manifest.json")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = f.Write(buf); err != nil {
+		return nil, err
+	}
+	if err = d.zip.Close(); err != nil {
+		return nil, err
+	}
+	return d.manifest, nil
+}
+
+type dossier struct {
+	zip      *zip.Writer
+	manifest *DossierManifest
+}
+
+func (d *dossier) add(name string, entry *DossierEntry, r io.Reader) error {
+	f, err := d.zip.Create(name)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	entry.Size, err = io.Copy(io.MultiWriter(f, h), r)
+	if err != nil {
+		return err
+	}
+	entry.Path = name
+	entry.SHA256 = hex.EncodeToString(h.Sum(nil))
+	d.manifest.Entries = append(d.manifest.Entries, *entry)
+	return nil
+}
+
+func (d *dossier) addJSON(name string, v interface{}) error {
+	buf, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return d.add(name, &DossierEntry{ContentType: "application/json"}, bytes.NewReader(buf))
+}
+
+func (d *dossier) addDocument(ctx context.Context, kyc *KYC, applicationID, fileID string) error {
+	tmp, err := ioutil.TempFile("", "dossier-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	info, err := kyc.DownloadFile(ctx, fileID, applicationID, tmp)
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	name := "documents/" + fileID
+	if base := path.Base(strings.Replace(info.Name, "\\", "/", -1)); info.Name != "" && base != "." && base != "/" {
+		name += "_" + base
+	} else if mediaType, _, err := mime.ParseMediaType(info.ContentType); err == nil {
+		if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	return d.add(name, &DossierEntry{FileID: fileID, Name: info.Name, ContentType: info.ContentType}, tmp)
+}
+
+// referencedFiles returns the ids of the files referenced by the
+// application, by fileID fields or by the id of files entries.
+func referencedFiles(data interface{}) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	var walk func(v interface{}, inFiles bool)
+	walk = func(v interface{}, inFiles bool) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(t))
+			for key := range t {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				id, isString := t[key].(string)
+				isFileID := strings.EqualFold(key, "fileID") || inFiles && key == "id"
+				if isString && isFileID && id != "" && !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+				walk(t[key], key == "files")
+			}
+		case []interface{}:
+			for _, item := range t {
+				walk(item, inFiles)
+			}
+		}
+	}
+	walk(data, false)
+	return ids
+}
+
+func writeDossierSummary(w io.Writer, manifest *DossierManifest, app map[string]interface{}, fiats int, changes []*HistoryChange) {
+	fmt.Fprintf(w, "KYC dossier of application %s\n", manifest.ApplicationID)
+	fmt.Fprintf(w, "Generated at: %s\n\n", manifest.GeneratedAt.Format(time.RFC3339))
+	for _, field := range []struct {
+		label string
+		keys  []string
+	}{
+		{"Type", []string{"type"}},
+		{"Identifier", []string{"identifier"}},
+		{"Status", []string{"status"}},
+		{"Operator", []string{"operator"}},
+	} {
+		if value := stringField(app, field.keys...); value != "" {
+			fmt.Fprintf(w, "%s: %s\n", field.label, value)
+		}
+	}
+	fmt.Fprintf(w, "Fiat accounts: %d\n\n", fiats)
+
+	fmt.Fprintln(w, "Documents:")
+	for _, entry := range manifest.Entries {
+		if entry.FileID != "" {
+			fmt.Fprintf(w, "  %s (%d bytes, sha256 %s)\n", entry.Path, entry.Size, entry.SHA256)
+		}
+	}
+
+	fmt.Fprintln(w, "\nHistory:")
+	WriteHistoryText(w, changes)
+}
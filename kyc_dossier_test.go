@@ -0,0 +1,120 @@
+package jadepoolsaas
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExportDossier(t *testing.T) {
+	files := map[string]string{"f1": "passport content", "f2": "utility bill"}
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		var data map[string]interface{}
+		switch r.URL.Path {
+		case "/api/v1/application/a1":
+			if r.URL.Query().Get("expand") != "true" {
+				t.Errorf("query = %s; want expand", r.URL.RawQuery)
+			}
+			data = map[string]interface{}{
+				"id":         "a1",
+				"type":       "individual",
+				"identifier": "alice",
+				"status":     "approved",
+				"documents": []interface{}{
+					map[string]interface{}{"type": "passport", "fileID": "f1"},
+				},
+				"files": []interface{}{
+					map[string]interface{}{"id": "f2", "name": "bill.pdf"},
+					map[string]interface{}{"id": "f1", "name": "passport.jpg"},
+				},
+			}
+		case "/api/v1/application/a1/fiats":
+			data = map[string]interface{}{"fiats": []interface{}{map[string]interface{}{"id": "fiat1"}}}
+		case "/api/v1/application/a1/histories":
+			data = map[string]interface{}{"histories": []interface{}{
+				map[string]interface{}{"id": "h1", "operator": "bob", "createdAt": "2024-01-01T00:00:00Z", "content": map[string]interface{}{"firstName": "Alice"}},
+			}}
+		case "/api/v1/file/f1", "/api/v1/file/f2":
+			id := strings.TrimPrefix(r.URL.Path, "/api/v1/file/")
+			if id == "f1" {
+				w.Header().Set("Content-Disposition", `attachment; filename="passport.jpg"`)
+			}
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte(files[id]))
+			return
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		_, err := writeSuccessResponse(w, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret)
+	var buf bytes.Buffer
+	manifest, err := kyc.ExportDossier(context.Background(), "a1", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := make(map[string][]byte)
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[f.Name], _ = ioutil.ReadAll(r)
+		r.Close()
+	}
+
+	for _, name := range []string{
+		"application.json",
+		"fiats.json",
+		"histories.json",
+		"documents/f1_passport.jpg",
+		"documents/f2.pdf",
+		"summary.txt",
+		"manifest.json",
+	} {
+		if _, ok := contents[name]; !ok {
+			t.Errorf("archive is missing %s", name)
+		}
+	}
+
+	var written DossierManifest
+	if err = json.Unmarshal(contents["manifest.json"], &written); err != nil {
+		t.Fatal(err)
+	}
+	if len(written.Entries) != 6 || len(manifest.Entries) != 6 {
+		t.Fatalf("entries = %+v; want 6", written.Entries)
+	}
+	for _, entry := range written.Entries {
+		sum := sha256.Sum256(contents[entry.Path])
+		if entry.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Errorf("sha256 of %s = %s; want checksum of the content", entry.Path, entry.SHA256)
+		}
+	}
+
+	summary := string(contents["summary.txt"])
+	for _, want := range []string{"application a1", "Status: approved", "Fiat accounts: 1", "documents/f2.pdf", "by bob"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary = %s; want %s", summary, want)
+		}
+	}
+}
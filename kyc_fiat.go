@@ -0,0 +1,229 @@
+package jadepoolsaas
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// FiatAccount is a bank account of an application.
+type FiatAccount struct {
+	ID            string `json:"id,omitempty"`
+	BankName      string `json:"bankName"`
+	AccountHolder string `json:"accountHolder"`
+	// IBAN or AccountNumber identifies the account.
+	IBAN          string `json:"iban,omitempty"`
+	AccountNumber string `json:"accountNumber,omitempty"`
+	// SWIFT is the SWIFT/BIC code of the bank.
+	SWIFT string `json:"swift"`
+	// Currency is an ISO 4217 code.
+	Currency    string  `json:"currency"`
+	BankAddress Address `json:"bankAddress"`
+}
+
+// Validate checks the fields of the fiat account offline.
+func (f *FiatAccount) Validate() error {
+	v := newValidator()
+	v.required("bankName", f.BankName)
+	v.required("accountHolder", f.AccountHolder)
+	if f.IBAN == "" && f.AccountNumber == "" {
+		v.add("iban", "iban or account number is required")
+	}
+	if f.IBAN != "" {
+		if err := ValidateIBAN(f.IBAN); err != nil {
+			v.add("iban", err.Error())
+		}
+	}
+	if v.required("swift", f.SWIFT) {
+		if err := ValidateBIC(f.SWIFT); err != nil {
+			v.add("swift", err.Error())
+		}
+	}
+	if v.required("currency", f.Currency) {
+		if err := ValidateCurrency(f.Currency); err != nil {
+			v.add("currency", err.Error())
+		}
+	}
+	f.BankAddress.validate(v.nested("bankAddress"))
+	return v.err()
+}
+
+// FiatAccountCreate validates and creates a fiat account with the application.
+func (k *KYC) FiatAccountCreate(applicationID string, account *FiatAccount) (*FiatAccount, error) {
+	if len(applicationID) == 0 {
+		return nil, errors.New("applicationID is empty")
+	}
+	if err := account.Validate(); err != nil {
+		return nil, err
+	}
+
+	content, err := fiatContent(account)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := checkResult(k.FiatCreate(applicationID, content))
+	if err != nil {
+		return nil, err
+	}
+
+	created := *account
+	if id, ok := ret.Data["id"]; ok && id != nil {
+		created.ID = fmt.Sprint(id)
+	}
+	return &created, nil
+}
+
+// FiatAccountsGet get the fiat accounts of the application.
+func (k *KYC) FiatAccountsGet(applicationID string) ([]*FiatAccount, error) {
+	if len(applicationID) == 0 {
+		return nil, errors.New("applicationID is empty")
+	}
+
+	ret, err := checkResult(k.FiatsGet(applicationID))
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []*FiatAccount
+	if err = decodeData(fiatList(ret.Data), &accounts); err != nil {
+		return nil, fmt.Errorf("parse fiat accounts failed: %v", err)
+	}
+	return accounts, nil
+}
+
+// FiatAccountUpdate validates and updates the fiat account with the same id.
+func (k *KYC) FiatAccountUpdate(account *FiatAccount) error {
+	if len(account.ID) == 0 {
+		return errors.New("fiat id is empty")
+	}
+	if err := account.Validate(); err != nil {
+		return err
+	}
+
+	content, err := fiatContent(account)
+	if err != nil {
+		return err
+	}
+	_, err = checkResult(k.FiatUpdate(account.ID, content))
+	return err
+}
+
+// FiatAccountDelete deletes the fiat account.
+func (k *KYC) FiatAccountDelete(fiatID string) error {
+	if len(fiatID) == 0 {
+		return errors.New("fiat id is empty")
+	}
+
+	_, err := checkResult(k.FiatDelete(fiatID))
+	return err
+}
+
+func fiatContent(account *FiatAccount) (map[string]interface{}, error) {
+	normalized := *account
+	normalized.ID = ""
+	normalized.IBAN = normalizeIBAN(account.IBAN)
+	normalized.SWIFT = strings.ToUpper(strings.TrimSpace(account.SWIFT))
+	normalized.Currency = strings.ToUpper(strings.TrimSpace(account.Currency))
+
+	content := make(map[string]interface{})
+	if err := decodeData(&normalized, &content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// dataList returns the list under key in the data of a result.
+func dataList(data map[string]interface{}, key string) []interface{} {
+	list, _ := data[key].([]interface{})
+	return list
+}
+
+// fiatList returns the fiat accounts in the data of a FiatsGet result.
+func fiatList(data map[string]interface{}) []interface{} {
+	return dataList(data, "fiats")
+}
+
+// ibanLengths is the length of the IBAN of every country using it.
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22,
+	"BH": 22, "BI": 27, "BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24,
+	"DE": 22, "DJ": 27, "DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24, "FI": 18,
+	"FK": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27,
+	"GT": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26, "IT": 27,
+	"JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32, "LI": 21, "LT": 20, "LU": 20,
+	"LV": 21, "LY": 25, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MN": 20, "MR": 27,
+	"MT": 31, "MU": 30, "NI": 28, "NL": 18, "NO": 15, "OM": 23, "PK": 24, "PL": 28,
+	"PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "RU": 33, "SA": 24, "SC": 31,
+	"SD": 18, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "SO": 23, "ST": 25, "SV": 28,
+	"TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20, "YE": 30,
+}
+
+var ibanRegexp = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]+$`)
+
+func normalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+// ValidateIBAN checks the format, country length and mod-97 checksum of the IBAN.
+// Spaces are ignored.
+func ValidateIBAN(iban string) error {
+	iban = normalizeIBAN(iban)
+	if !ibanRegexp.MatchString(iban) {
+		return errors.New("invalid iban format")
+	}
+	length, ok := ibanLengths[iban[:2]]
+	if !ok {
+		return fmt.Errorf("iban is not used in country %s", iban[:2])
+	}
+	if len(iban) != length {
+		return fmt.Errorf("iban of country %s must have %d characters", iban[:2], length)
+	}
+
+	var digits strings.Builder
+	for _, c := range iban[4:] + iban[:4] {
+		if c >= 'A' && c <= 'Z' {
+			digits.WriteString(fmt.Sprint(c - 'A' + 10))
+		} else {
+			digits.WriteRune(c)
+		}
+	}
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	if new(big.Int).Mod(n, big.NewInt(97)).Int64() != 1 {
+		return errors.New("invalid iban checksum")
+	}
+	return nil
+}
+
+var bicRegexp = regexp.MustCompile(`^[A-Z]{4}[A-Z]{2}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+
+// ValidateBIC checks the format of the SWIFT/BIC code.
+func ValidateBIC(bic string) error {
+	if !bicRegexp.MatchString(strings.ToUpper(strings.TrimSpace(bic))) {
+		return errors.New("invalid swift/bic format")
+	}
+	return nil
+}
+
+// currencyCodes are the active ISO 4217 currency codes.
+var currencyCodes = strings.Fields(`
+AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BOV
+BRL BSD BTN BWP BYN BZD CAD CDF CHE CHF CHW CLF CLP CNY COP COU CRC CUC CUP CVE
+CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF GTQ GYD HKD
+HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD
+KZT LAK LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV
+MYR MZN NAD NGN NIO NOK NPR NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB
+RWF SAR SBD SCR SDG SEK SGD SHP SLE SLL SOS SRD SSP STN SVC SYP SZL THB TJS TMT
+TND TOP TRY TTD TWD TZS UAH UGX USD USN UYI UYU UYW UZS VED VES VND VUV WST XAF
+XAG XAU XBA XBB XBC XBD XCD XDR XOF XPD XPF XPT XSU XTS XUA XXX YER ZAR ZMW ZWL
+`)
+
+// ValidateCurrency checks that code is an active ISO 4217 currency code.
+func ValidateCurrency(code string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if containsString(currencyCodes, code) {
+		return nil
+	}
+	return fmt.Errorf("unknown ISO 4217 currency %s", code)
+}
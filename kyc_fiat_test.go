@@ -0,0 +1,122 @@
+package jadepoolsaas
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func testFiatAccount() *FiatAccount {
+	return &FiatAccount{
+		BankName:      "Barclays",
+		AccountHolder: "Alice Smith",
+		IBAN:          "gb82 west 1234 5698 7654 32",
+		SWIFT:         "BARCGB22",
+		Currency:      "gbp",
+		BankAddress:   Address{Line1: "1 Churchill Place", City: "London", Country: "GB"},
+	}
+}
+
+func TestValidateIBAN(t *testing.T) {
+	for iban, valid := range map[string]bool{
+		"GB82WEST12345698765432":      true,
+		"DE89 3704 0044 0532 0130 00": true,
+		"FR1420041010050500013M02606": true,
+		"GB82WEST12345698765433":      false,
+		"GB82WEST1234569876543":       false,
+		"US82WEST12345698765432":      false,
+		"GB8":                         false,
+	} {
+		if err := ValidateIBAN(iban); (err == nil) != valid {
+			t.Errorf("ValidateIBAN(%s) = %v; want valid %v", iban, err, valid)
+		}
+	}
+}
+
+func TestFiatAccountValidate(t *testing.T) {
+	if err := testFiatAccount().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	account := &FiatAccount{BankName: "Barclays", SWIFT: "BARC22", Currency: "GBX"}
+	var errs ValidationErrors
+	if !errors.As(account.Validate(), &errs) {
+		t.Fatalf("err = %v; want ValidationErrors", account.Validate())
+	}
+	fields := make(map[string]bool)
+	for _, fieldErr := range errs {
+		fields[fieldErr.Field] = true
+	}
+	for _, field := range []string{"accountHolder", "iban", "swift", "currency", "bankAddress.line1"} {
+		if !fields[field] {
+			t.Errorf("errors = %v; want error for %s", errs, field)
+		}
+	}
+}
+
+func TestFiatAccountCRUD(t *testing.T) {
+	var body map[string]interface{}
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+
+		data := map[string]interface{}{}
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/v1/application/a1/fiat":
+			data["id"] = "f1"
+		case r.Method == "GET" && r.URL.Path == "/api/v1/application/a1/fiats":
+			data["fiats"] = []interface{}{
+				map[string]interface{}{"id": "f1", "bankName": "Barclays", "iban": "GB82WEST12345698765432", "currency": "GBP"},
+			}
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/fiat/f1":
+		case r.Method == "DELETE" && r.URL.Path == "/api/v1/fiat/f1":
+		default:
+			writeErrorResponse(w, 404, "not found")
+			return
+		}
+
+		_, err := writeSuccessResponse(w, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret)
+	account, err := kyc.FiatAccountCreate("a1", testFiatAccount())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.ID != "f1" {
+		t.Errorf("id = %s; want f1", account.ID)
+	}
+	if body["iban"] != "GB82WEST12345698765432" || body["currency"] != "GBP" {
+		t.Errorf("body = %v; want normalized iban and currency", body)
+	}
+
+	accounts, err := kyc.FiatAccountsGet("a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 1 || accounts[0].ID != "f1" || accounts[0].Currency != "GBP" {
+		t.Errorf("accounts = %+v; want f1", accounts)
+	}
+
+	account.Currency = "EUR"
+	if err = kyc.FiatAccountUpdate(account); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := body["id"]; ok || body["currency"] != "EUR" {
+		t.Errorf("body = %v; want updated currency without id", body)
+	}
+
+	if err = kyc.FiatAccountDelete("f1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = kyc.FiatAccountDelete("f2"); err == nil {
+		t.Error("want api error")
+	}
+}
@@ -0,0 +1,191 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// FileInfo is the metadata of a kyc file.
+type FileInfo struct {
+	ID            string `json:"id"`
+	ApplicationID string `json:"applicationID"`
+	Name          string `json:"name"`
+	Size          int64  `json:"size"`
+	ContentType   string `json:"contentType"`
+	SHA256        string `json:"sha256"`
+	// Verified reports whether SHA256 was checked against a digest announced
+	// by the server or given by WithSHA256, it is false if none was available.
+	Verified bool `json:"-"`
+}
+
+// FileOption configures a file transfer.
+type FileOption func(*fileTransfer)
+
+type fileTransfer struct {
+	progress      func(transferred, total int64)
+	sha256        string
+	requireDigest bool
+}
+
+// WithProgress reports the transferred bytes during a file transfer, total is -1 if unknown.
+func WithProgress(progress func(transferred, total int64)) FileOption {
+	return func(t *fileTransfer) {
+		t.progress = progress
+	}
+}
+
+// WithSHA256 verifies the downloaded content against the hex sha256 digest,
+// usually taken from the FileInfo returned by UploadFile.
+func WithSHA256(digest string) FileOption {
+	return func(t *fileTransfer) {
+		t.sha256 = digest
+	}
+}
+
+// RequireDigest fails a download with ErrDigestUnavailable, before any
+// content is written, when neither the response headers nor WithSHA256
+// provide a digest to verify it against.
+func RequireDigest() FileOption {
+	return func(t *fileTransfer) {
+		t.requireDigest = true
+	}
+}
+
+func newFileTransfer(opts []FileOption) *fileTransfer {
+	t := &fileTransfer{}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// UploadFile streams the content of r as a file of the application without
+// buffering it in memory. size is only used to report progress, -1 if unknown.
+// The sha256 digest of the content is sent in the signed params, readers which
+// are not seekable are spooled to a temporary file to compute it.
+func (k *KYC) UploadFile(ctx context.Context, applicationID, name string, r io.Reader, size int64, opts ...FileOption) (*FileInfo, error) {
+	t := newFileTransfer(opts)
+
+	digest, r, cleanup, err := digestReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	if t.progress != nil {
+		r = &progressReader{Reader: r, total: size, progress: t.progress}
+	}
+
+	ret, err := k.WithContext(ctx).session.postReader("/api/v1/file", name, r, map[string]interface{}{
+		"applicationID": applicationID,
+		"sha256":        digest,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var info FileInfo
+	if err = decodeData(ret.Data, &info); err != nil {
+		return nil, fmt.Errorf("parse file info failed: %v", err)
+	}
+	if info.ApplicationID == "" {
+		info.ApplicationID = applicationID
+	}
+	if info.Name == "" {
+		info.Name = name
+	}
+	if err = verifyDigest(info.SHA256, digest); err != nil {
+		return nil, err
+	}
+	info.Verified = info.SHA256 != ""
+	info.SHA256 = digest
+	return &info, nil
+}
+
+// DownloadFile streams the content of the file into w. The content is verified
+// against the digest announced by the response headers and the one given by
+// WithSHA256, an *IntegrityError is returned after the copy if either mismatches.
+// Without any digest the content is not verified and Verified is false,
+// unless RequireDigest is given.
+func (k *KYC) DownloadFile(ctx context.Context, fileID, applicationID string, w io.Writer, opts ...FileOption) (*FileInfo, error) {
+	t := newFileTransfer(opts)
+
+	r, err := k.WithContext(ctx).session.getFile2("/api/v1/file/"+fileID, map[string]interface{}{
+		"applicationID": applicationID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := r.Response()
+	defer response.Body.Close()
+	if t.requireDigest && t.sha256 == "" && headerDigest(response.Header) == "" {
+		return nil, ErrDigestUnavailable
+	}
+
+	info := fileInfoFromResponse(response)
+	info.ID = fileID
+	info.ApplicationID = applicationID
+	if t.progress != nil {
+		w = &progressWriter{Writer: w, total: response.ContentLength, progress: t.progress}
+	}
+	hw := newHashingWriter(w)
+
+	info.Size, err = io.Copy(hw, response.Body)
+	if err != nil {
+		return nil, err
+	}
+	info.SHA256 = hw.digest()
+	for _, expected := range []string{headerDigest(response.Header), t.sha256} {
+		if err = verifyDigest(expected, info.SHA256); err != nil {
+			return nil, err
+		}
+		if expected != "" {
+			info.Verified = true
+		}
+	}
+	return info, nil
+}
+
+func fileInfoFromResponse(response *http.Response) *FileInfo {
+	info := &FileInfo{
+		ContentType: response.Header.Get("Content-Type"),
+	}
+	if _, params, err := mime.ParseMediaType(response.Header.Get("Content-Disposition")); err == nil {
+		info.Name = params["filename"]
+	}
+	return info
+}
+
+type progressReader struct {
+	io.Reader
+	transferred int64
+	total       int64
+	progress    func(transferred, total int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.transferred += int64(n)
+		r.progress(r.transferred, r.total)
+	}
+	return n, err
+}
+
+type progressWriter struct {
+	io.Writer
+	transferred int64
+	total       int64
+	progress    func(transferred, total int64)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	if n > 0 {
+		w.transferred += int64(n)
+		w.progress(w.transferred, w.total)
+	}
+	return n, err
+}
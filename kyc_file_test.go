@@ -0,0 +1,162 @@
+package jadepoolsaas
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUploadFile(t *testing.T) {
+	content := bytes.Repeat([]byte("passport"), 4096)
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+	response := map[string]interface{}{
+		"id":   "f1",
+		"size": len(content),
+	}
+
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("applicationID") != "a1" || r.FormValue("sign") == "" {
+			t.Errorf("form = %v; want signed applicationID", r.Form)
+		}
+		if r.FormValue("sha256") != digest {
+			t.Errorf("sha256 = %s; want %s", r.FormValue("sha256"), digest)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, _ := ioutil.ReadAll(file)
+		if header.Filename != "passport.jpg" || !bytes.Equal(buf, content) {
+			t.Errorf("file = %s, %d bytes; want passport.jpg, %d bytes", header.Filename, len(buf), len(content))
+		}
+
+		_, err = writeSuccessResponse(w, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	var transferred int64
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret)
+	info, err := kyc.UploadFile(context.Background(), "a1", "passport.jpg", bytes.NewBuffer(content), int64(len(content)),
+		WithProgress(func(n, total int64) {
+			transferred = n
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != "f1" || info.Name != "passport.jpg" || info.Size != int64(len(content)) || info.SHA256 != digest {
+		t.Errorf("info = %+v; want f1 passport.jpg", info)
+	}
+	if transferred != int64(len(content)) {
+		t.Errorf("transferred = %d; want %d", transferred, len(content))
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	content := bytes.Repeat([]byte("passport"), 4096)
+
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("applicationID") != "a1" {
+			t.Errorf("applicationID = %s; want a1", r.URL.Query().Get("applicationID"))
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Content-Disposition", `attachment; filename="passport.jpg"`)
+		w.Write(content)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	var transferred int64
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret)
+	info, err := kyc.DownloadFile(context.Background(), "f1", "a1", &buf, WithProgress(func(n, total int64) {
+		transferred = n
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), content) {
+		t.Errorf("content = %d bytes; want %d bytes", buf.Len(), len(content))
+	}
+	if info.ID != "f1" || info.Name != "passport.jpg" || info.ContentType != "image/jpeg" || info.Size != int64(len(content)) {
+		t.Errorf("info = %+v; want f1 passport.jpg image/jpeg", info)
+	}
+	if info.Verified {
+		t.Error("verified = true; want false without any digest")
+	}
+
+	buf.Reset()
+	if _, err = kyc.DownloadFile(context.Background(), "f1", "a1", &buf, RequireDigest()); err != ErrDigestUnavailable || buf.Len() != 0 {
+		t.Errorf("err = %v, %d bytes written; want ErrDigestUnavailable without content", err, buf.Len())
+	}
+	if transferred != int64(len(content)) {
+		t.Errorf("transferred = %d; want %d", transferred, len(content))
+	}
+}
+
+func TestDownloadIntegrity(t *testing.T) {
+	content := []byte("passport")
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+
+	headerDigest := digest
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-SHA256", headerDigest)
+		w.Write(content)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret)
+	var buf bytes.Buffer
+	info, err := kyc.DownloadFile(context.Background(), "f1", "a1", &buf, WithSHA256(digest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Verified {
+		t.Error("verified = false; want true")
+	}
+
+	var integrityErr *IntegrityError
+	_, err = kyc.DownloadFile(context.Background(), "f1", "a1", &buf, WithSHA256(strings.Repeat("0", 64)))
+	if !errors.As(err, &integrityErr) {
+		t.Errorf("err = %v; want IntegrityError", err)
+	}
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "passport.jpg")
+
+	headerDigest = strings.Repeat("0", 64)
+	_, err = kyc.FileGet("f1", filePath)
+	if !errors.As(err, &integrityErr) {
+		t.Errorf("err = %v; want IntegrityError", err)
+	}
+	if _, err = os.Stat(filePath); !os.IsNotExist(err) {
+		t.Errorf("corrupted file is written, stat err = %v", err)
+	}
+
+	headerDigest = digest
+	if _, err = kyc.FileGet("f1", filePath); err != nil {
+		t.Fatal(err)
+	}
+	if buf, _ := ioutil.ReadFile(filePath); !bytes.Equal(buf, content) {
+		t.Errorf("file content = %s; want %s", buf, content)
+	}
+}
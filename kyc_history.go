@@ -0,0 +1,253 @@
+package jadepoolsaas
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// HistoryRecord is a snapshot of the application content saved by a change,
+// an entry of the histories list returned by ApplicationHistoriesGet. Content
+// is nil for changes not saving the content, such as a submission.
+type HistoryRecord struct {
+	ID        string                 `json:"id"`
+	Operator  string                 `json:"operator"`
+	Action    string                 `json:"action,omitempty"`
+	CreatedAt time.Time              `json:"createdAt"`
+	Content   map[string]interface{} `json:"content"`
+}
+
+// FieldChange is a field modified by a change, Old is nil for added fields
+// and New is nil for removed fields.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// HistoryChange lists the fields modified by a history record.
+type HistoryChange struct {
+	RecordID string        `json:"recordID"`
+	Operator string        `json:"operator"`
+	Action   string        `json:"action,omitempty"`
+	Time     time.Time     `json:"time"`
+	Fields   []FieldChange `json:"fields"`
+}
+
+// ApplicationHistoryRecords get the typed change histories with the application.
+func (k *KYC) ApplicationHistoryRecords(applicationID string) ([]*HistoryRecord, error) {
+	if len(applicationID) == 0 {
+		return nil, errors.New("applicationID is empty")
+	}
+
+	ret, err := checkResult(k.ApplicationHistoriesGet(applicationID))
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*HistoryRecord
+	for _, item := range dataList(ret.Data, "histories") {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("parse history failed: unexpected entry %v", item)
+		}
+		record, err := parseHistoryRecord(entry)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ApplicationHistoryChanges get the field changes with the application,
+// oldest first.
+func (k *KYC) ApplicationHistoryChanges(applicationID string) ([]*HistoryChange, error) {
+	records, err := k.ApplicationHistoryRecords(applicationID)
+	if err != nil {
+		return nil, err
+	}
+	return DiffHistories(records), nil
+}
+
+// DiffHistories compares every record with the one before it in time, the
+// first record is compared with an empty content. Nested fields are named by
+// their path, such as address.city or documents[1].fileID. Records without
+// content modify no fields and are skipped by the comparison.
+func DiffHistories(records []*HistoryRecord) []*HistoryChange {
+	sorted := make([]*HistoryRecord, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	changes := make([]*HistoryChange, 0, len(sorted))
+	prev := map[string]interface{}{}
+	for _, record := range sorted {
+		change := &HistoryChange{
+			RecordID: record.ID,
+			Operator: record.Operator,
+			Action:   record.Action,
+			Time:     record.CreatedAt,
+			Fields:   []FieldChange{},
+		}
+		if record.Content != nil {
+			diffValues("", prev, record.Content, &change.Fields)
+			prev = record.Content
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func diffValues(field string, old, new interface{}, changes *[]FieldChange) {
+	if reflect.DeepEqual(old, new) {
+		return
+	}
+
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap || field == "" {
+		keys := make(map[string]bool)
+		for key := range oldMap {
+			keys[key] = true
+		}
+		for key := range newMap {
+			keys[key] = true
+		}
+		sortedKeys := make([]string, 0, len(keys))
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+		sort.Strings(sortedKeys)
+
+		for _, key := range sortedKeys {
+			name := key
+			if field != "" {
+				name = field + "." + key
+			}
+			diffValues(name, oldMap[key], newMap[key], changes)
+		}
+		return
+	}
+
+	oldList, oldIsList := old.([]interface{})
+	newList, newIsList := new.([]interface{})
+	if oldIsList && newIsList {
+		n := len(oldList)
+		if len(newList) > n {
+			n = len(newList)
+		}
+		for i := 0; i < n; i++ {
+			var o, v interface{}
+			if i < len(oldList) {
+				o = oldList[i]
+			}
+			if i < len(newList) {
+				v = newList[i]
+			}
+			diffValues(field+"["+strconv.Itoa(i)+"]", o, v, changes)
+		}
+		return
+	}
+
+	*changes = append(*changes, FieldChange{Field: field, Old: old, New: new})
+}
+
+// WriteHistoryText renders the changes for reading, one line per modified field.
+func WriteHistoryText(w io.Writer, changes []*HistoryChange) error {
+	for _, change := range changes {
+		action := change.Action
+		if action == "" {
+			action = "change"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s by %s\n", change.Time.UTC().Format(time.RFC3339), action, change.Operator); err != nil {
+			return err
+		}
+		for _, field := range change.Fields {
+			if _, err := fmt.Fprintf(w, "  %s: %s -> %s\n", field.Field, historyValue(field.Old), historyValue(field.New)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteHistoryJSON renders the changes as an indented json array.
+func WriteHistoryJSON(w io.Writer, changes []*HistoryChange) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(changes)
+}
+
+func historyValue(v interface{}) string {
+	if v == nil {
+		return "(none)"
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(buf)
+}
+
+func parseHistoryRecord(entry map[string]interface{}) (*HistoryRecord, error) {
+	record := &HistoryRecord{
+		ID:       stringField(entry, "id"),
+		Operator: stringField(entry, "operator"),
+		Action:   stringField(entry, "action"),
+	}
+	if content, ok := entry["content"]; ok && content != nil {
+		if record.Content, ok = content.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("parse history %s failed: unexpected content %v", record.ID, content)
+		}
+	}
+	if v, ok := entry["createdAt"]; ok && v != nil {
+		t, err := parseHistoryTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse history %s failed: %v", record.ID, err)
+		}
+		record.CreatedAt = t
+	}
+	return record, nil
+}
+
+// parseHistoryTime accepts RFC 3339 strings and unix times in seconds or
+// milliseconds.
+func parseHistoryTime(v interface{}) (time.Time, error) {
+	var unix float64
+	switch t := v.(type) {
+	case float64:
+		unix = t
+	case string:
+		if parsed, err := time.Parse(time.RFC3339, t); err == nil {
+			return parsed, nil
+		}
+		n, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %s", t)
+		}
+		unix = n
+	default:
+		return time.Time{}, fmt.Errorf("invalid time %v", v)
+	}
+
+	if unix > 1e11 {
+		return time.Unix(0, int64(unix)*int64(time.Millisecond)), nil
+	}
+	return time.Unix(int64(unix), 0), nil
+}
+
+func stringField(data map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if v, ok := data[key]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
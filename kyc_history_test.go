@@ -0,0 +1,101 @@
+package jadepoolsaas
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestApplicationHistoryChanges(t *testing.T) {
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/application/a1/histories" {
+			t.Errorf("path = %s; want histories", r.URL.Path)
+		}
+
+		_, err := writeSuccessResponse(w, map[string]interface{}{
+			"histories": []interface{}{
+				map[string]interface{}{
+					"id":        "h3",
+					"operator":  "bob",
+					"action":    "submit",
+					"createdAt": "2024-01-03T00:00:00Z",
+				},
+				map[string]interface{}{
+					"id":        "h2",
+					"operator":  "bob",
+					"action":    "update",
+					"createdAt": "2024-01-02T00:00:00Z",
+					"content": map[string]interface{}{
+						"firstName": "Alice",
+						"address":   map[string]interface{}{"city": "Manchester"},
+						"documents": []interface{}{"f1", "f2"},
+					},
+				},
+				map[string]interface{}{
+					"id":        "h1",
+					"operator":  "alice",
+					"action":    "create",
+					"createdAt": 1704067200000,
+					"content": map[string]interface{}{
+						"firstName": "Alice",
+						"lastName":  "Smith",
+						"address":   map[string]interface{}{"city": "London"},
+						"documents": []interface{}{"f1"},
+					},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret)
+	changes, err := kyc.ApplicationHistoryChanges("a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 || changes[0].RecordID != "h1" || len(changes[0].Fields) != 4 {
+		t.Fatalf("changes = %+v; want h1 creating 4 fields first", changes)
+	}
+	if changes[2].RecordID != "h3" || len(changes[2].Fields) != 0 {
+		t.Errorf("change = %+v; want h3 submitting without field changes", changes[2])
+	}
+
+	want := []FieldChange{
+		{Field: "address.city", Old: "London", New: "Manchester"},
+		{Field: "documents[1]", Old: nil, New: "f2"},
+		{Field: "lastName", Old: "Smith", New: nil},
+	}
+	fields := changes[1].Fields
+	if len(fields) != len(want) {
+		t.Fatalf("fields = %+v; want %+v", fields, want)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("fields[%d] = %+v; want %+v", i, fields[i], want[i])
+		}
+	}
+
+	var text bytes.Buffer
+	if err = WriteHistoryText(&text, changes); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "2024-01-02T00:00:00Z update by bob\n  address.city: \"London\" -> \"Manchester\"\n") {
+		t.Errorf("text = %s; want update by bob", text.String())
+	}
+
+	var buf bytes.Buffer
+	if err = WriteHistoryJSON(&buf, changes); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []*HistoryChange
+	if err = json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 3 {
+		t.Errorf("json = %s, %v; want 3 changes", buf.String(), err)
+	}
+}
@@ -0,0 +1,283 @@
+package jadepoolsaas
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+
+	"golang.org/x/image/draw"
+)
+
+// Default image preprocessing limits.
+const (
+	DefaultImageMaxDimension = 2048
+	DefaultImageMaxSize      = 2 << 20
+	DefaultImageMinDimension = 600
+	DefaultImageQuality      = 85
+	DefaultImageMaxPixels    = 50000000
+	DefaultImageMaxInputSize = 50 << 20
+)
+
+// ErrImageTooSmall is returned for images below the minimum resolution.
+var ErrImageTooSmall = errors.New("image resolution is too small")
+
+// ImageOptions configures the image preprocessing, zero values use the defaults.
+type ImageOptions struct {
+	// MaxDimension is the maximum width and height, larger images are downsized.
+	MaxDimension int
+	// MaxSize is the maximum size of the encoded image in bytes.
+	MaxSize int
+	// MinDimension is the minimum length of the shorter side.
+	MinDimension int
+	// Quality is the initial jpeg quality, it is lowered to fit MaxSize.
+	Quality int
+	// MaxPixels is the maximum width times height of the image read, larger
+	// images are rejected before being decoded.
+	MaxPixels int
+	// MaxInputSize is the maximum size of the image read in bytes.
+	MaxInputSize int64
+}
+
+func (opts *ImageOptions) maxInputSize() int64 {
+	if opts.MaxInputSize <= 0 {
+		return DefaultImageMaxInputSize
+	}
+	return opts.MaxInputSize
+}
+
+// PreprocessImage prepares a jpeg or png photo for upload: it is rotated
+// upright according to its exif orientation, downsized to fit MaxDimension
+// and re-encoded in its format without any metadata, lowering the quality
+// and then the dimensions until it fits MaxSize. Images with a side shorter
+// than MinDimension are rejected with ErrImageTooSmall, images over
+// MaxInputSize or MaxPixels are rejected before being decoded.
+func PreprocessImage(r io.Reader, opts ImageOptions) ([]byte, string, error) {
+	if opts.MaxDimension <= 0 {
+		opts.MaxDimension = DefaultImageMaxDimension
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultImageMaxSize
+	}
+	if opts.MinDimension <= 0 {
+		opts.MinDimension = DefaultImageMinDimension
+	}
+	if opts.Quality <= 0 || opts.Quality > 100 {
+		opts.Quality = DefaultImageQuality
+	}
+	if opts.MaxPixels <= 0 {
+		opts.MaxPixels = DefaultImageMaxPixels
+	}
+
+	maxInputSize := opts.maxInputSize()
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxInputSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > maxInputSize {
+		return nil, "", fmt.Errorf("image size exceeds the limit %d", maxInputSize)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("decode image failed: %v", err)
+	}
+	if int64(config.Width)*int64(config.Height) > int64(opts.MaxPixels) {
+		return nil, "", fmt.Errorf("image of %dx%d pixels exceeds the limit %d", config.Width, config.Height, opts.MaxPixels)
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("decode image failed: %v", err)
+	}
+	if format != "jpeg" && format != "png" {
+		return nil, "", fmt.Errorf("unsupported image format %s", format)
+	}
+
+	oriented := toNRGBA(img)
+	if format == "jpeg" {
+		oriented = orient(oriented, jpegOrientation(data))
+	}
+	bounds := oriented.Bounds()
+	if minInt(bounds.Dx(), bounds.Dy()) < opts.MinDimension {
+		return nil, "", ErrImageTooSmall
+	}
+
+	scale := 1.0
+	if longest := maxInt(bounds.Dx(), bounds.Dy()); longest > opts.MaxDimension {
+		scale = float64(opts.MaxDimension) / float64(longest)
+	}
+	for {
+		resized := resize(oriented, scale)
+		if format == "png" {
+			var buf bytes.Buffer
+			encoder := png.Encoder{CompressionLevel: png.BestCompression}
+			if err = encoder.Encode(&buf, resized); err != nil {
+				return nil, "", err
+			}
+			if buf.Len() <= opts.MaxSize {
+				return buf.Bytes(), "image/png", nil
+			}
+		} else {
+			for quality := opts.Quality; quality >= 40; quality -= 10 {
+				var buf bytes.Buffer
+				if err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: quality}); err != nil {
+					return nil, "", err
+				}
+				if buf.Len() <= opts.MaxSize {
+					return buf.Bytes(), "image/jpeg", nil
+				}
+			}
+		}
+
+		scale *= 0.75
+		if float64(minInt(bounds.Dx(), bounds.Dy()))*scale < float64(opts.MinDimension) {
+			return nil, "", fmt.Errorf("image cannot fit %d bytes above the minimum resolution", opts.MaxSize)
+		}
+	}
+}
+
+// FileUploadImage preprocesses the image with PreprocessImage and uploads it
+// with FileUpload2.
+func (k *KYC) FileUploadImage(applicationID, fileName string, r io.Reader, opts ImageOptions) (*Result, error) {
+	content, _, err := PreprocessImage(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return k.FileUpload2(applicationID, fileName, bytes.NewReader(content))
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == (image.Point{}) {
+		return nrgba
+	}
+	bounds := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(nrgba, nrgba.Rect, img, bounds.Min, draw.Src)
+	return nrgba
+}
+
+func resize(img *image.NRGBA, scale float64) *image.NRGBA {
+	if scale >= 1 {
+		return img
+	}
+	bounds := img.Bounds()
+	width := maxInt(1, int(float64(bounds.Dx())*scale+0.5))
+	height := maxInt(1, int(float64(bounds.Dy())*scale+0.5))
+	resized := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(resized, resized.Rect, img, bounds, draw.Src, nil)
+	return resized
+}
+
+// orient transforms the image so that it is displayed upright for the exif
+// orientation.
+func orient(img *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], img.Pix[img.PixOffset(x, y):img.PixOffset(x, y)+4])
+		}
+	}
+	return dst
+}
+
+// jpegOrientation returns the exif orientation of a jpeg, 1 if it has none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return 1
+		}
+		segment := data[i+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i = end
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
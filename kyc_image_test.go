@@ -0,0 +1,113 @@
+package jadepoolsaas
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// testJPEG encodes a w x h jpeg, red on the left half and blue on the right,
+// with an exif segment holding the orientation.
+func testJPEG(t *testing.T, w, h int, orientation uint16) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatal(err)
+	}
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = append(tiff, 0, 1)
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry, 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(append(tiff, entry...), 0, 0, 0, 0)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), append(app1, segment...)...), data[2:]...)
+}
+
+func TestPreprocessImage(t *testing.T) {
+	data := testJPEG(t, 800, 600, 6)
+	if jpegOrientation(data) != 6 {
+		t.Fatalf("orientation = %d; want 6", jpegOrientation(data))
+	}
+
+	out, contentType, err := PreprocessImage(bytes.NewReader(data), ImageOptions{MaxDimension: 400, MinDimension: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "image/jpeg" {
+		t.Errorf("content type = %s; want image/jpeg", contentType)
+	}
+	if bytes.Contains(out, []byte("Exif")) {
+		t.Error("want exif stripped")
+	}
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size != image.Pt(300, 400) {
+		t.Errorf("size = %v; want rotated and downsized to 300x400", size)
+	}
+	// rotated clockwise, the red left half is now on top.
+	if r, _, b, _ := img.At(150, 50).RGBA(); r < b {
+		t.Errorf("top = %v; want red", img.At(150, 50))
+	}
+
+	if _, _, err = PreprocessImage(bytes.NewReader(data), ImageOptions{MinDimension: 700}); err != ErrImageTooSmall {
+		t.Errorf("err = %v; want ErrImageTooSmall", err)
+	}
+
+	var buf bytes.Buffer
+	noisy := image.NewNRGBA(image.Rect(0, 0, 400, 400))
+	for i := range noisy.Pix {
+		noisy.Pix[i] = byte(i * 7919 % 251)
+	}
+	png.Encode(&buf, noisy)
+	out, contentType, err = PreprocessImage(&buf, ImageOptions{MaxSize: 100000, MinDimension: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "image/png" || len(out) > 100000 {
+		t.Errorf("png = %s of %d bytes; want at most 100000", contentType, len(out))
+	}
+}
+
+func TestPreprocessImageLimits(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	// declare 50000x50000 pixels in the IHDR chunk of the 1x1 png
+	bomb := buf.Bytes()
+	binary.BigEndian.PutUint32(bomb[16:], 50000)
+	binary.BigEndian.PutUint32(bomb[20:], 50000)
+	binary.BigEndian.PutUint32(bomb[29:], crc32.ChecksumIEEE(bomb[12:29]))
+
+	_, _, err := PreprocessImage(bytes.NewReader(bomb), ImageOptions{})
+	if err == nil || err.Error() != "image of 50000x50000 pixels exceeds the limit 50000000" {
+		t.Errorf("err = %v; want pixel limit exceeded", err)
+	}
+	if _, _, err = PreprocessImage(bytes.NewReader(bomb), ImageOptions{MaxInputSize: 10}); err == nil {
+		t.Error("want input size limit exceeded")
+	}
+}
@@ -0,0 +1,320 @@
+package jadepoolsaas
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// Import actions recorded in the result log.
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportFailed  = "failed"
+)
+
+// ImportRow is an application read from an import file.
+type ImportRow struct {
+	Line       int
+	Type       string
+	Identifier string
+	Operator   string
+	Fields     map[string]interface{}
+
+	// err is set for a row which cannot be parsed.
+	err error
+}
+
+// ImportResult is a line of the result log.
+type ImportResult struct {
+	Line          int       `json:"line"`
+	Type          string    `json:"type"`
+	Identifier    string    `json:"identifier"`
+	Hash          string    `json:"hash"`
+	ApplicationID string    `json:"applicationID,omitempty"`
+	Action        string    `json:"action"`
+	Error         string    `json:"error,omitempty"`
+	Time          time.Time `json:"time"`
+}
+
+// ImportSummary counts the rows processed by an import.
+type ImportSummary struct {
+	Created int             `json:"created"`
+	Updated int             `json:"updated"`
+	Skipped int             `json:"skipped"`
+	Failed  int             `json:"failed"`
+	Results []*ImportResult `json:"results"`
+}
+
+// Importer creates or updates applications from CSV or JSON Lines files.
+//
+// A row is identified by its type and identifier columns, the operator
+// column is used when creating the application and every other column is a
+// field patched with ApplicationUpdate2. CSV headers with dots, such as
+// address.city, set nested fields, and empty CSV cells are ignored. The
+// application is created if the lookup by identifier reports it missing, by a
+// 404 status or a result code set with WithNotFoundCodes.
+type Importer struct {
+	KYC *KYC
+	// Log is the path of the result log, a JSON Lines file appended with the
+	// result of every row. Rows logged as created or updated with the same
+	// content are skipped, so an interrupted import can be run again.
+	Log string
+	// Type and Operator are used for rows without them.
+	Type     string
+	Operator string
+}
+
+// ImportCSV imports the rows of a CSV file with a header line. A row which
+// cannot be parsed, such as one with a wrong number of fields, is logged as
+// failed.
+func (im *Importer) ImportCSV(ctx context.Context, r io.Reader) (*ImportSummary, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header failed: %v", err)
+	}
+
+	line := 1
+	return im.run(ctx, func() (*ImportRow, error) {
+		record, err := reader.Read()
+		if _, ok := err.(*csv.ParseError); ok {
+			line++
+			return &ImportRow{Line: line, err: err}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line++
+
+		values := make(map[string]interface{})
+		for i, value := range record {
+			if i >= len(header) || value == "" {
+				continue
+			}
+			setField(values, strings.Split(header[i], "."), value)
+		}
+		return im.newRow(line, values), nil
+	})
+}
+
+// ImportJSONL imports the rows of a JSON Lines file, an object per line. A
+// line which is not a JSON object is logged as failed.
+func (im *Importer) ImportJSONL(ctx context.Context, r io.Reader) (*ImportSummary, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+
+	line := 0
+	return im.run(ctx, func() (*ImportRow, error) {
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			values := make(map[string]interface{})
+			if err := json.Unmarshal([]byte(text), &values); err != nil {
+				return &ImportRow{Line: line, err: fmt.Errorf("parse line %d failed: %v", line, err)}, nil
+			}
+			return im.newRow(line, values), nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	})
+}
+
+func (im *Importer) newRow(line int, values map[string]interface{}) *ImportRow {
+	row := &ImportRow{Line: line, Type: im.Type, Operator: im.Operator}
+	for _, column := range []struct {
+		key   string
+		value *string
+	}{
+		{"type", &row.Type},
+		{"identifier", &row.Identifier},
+		{"operator", &row.Operator},
+	} {
+		if value, ok := values[column.key]; ok {
+			if s := fmt.Sprint(value); value != nil && s != "" {
+				*column.value = s
+			}
+			delete(values, column.key)
+		}
+	}
+	row.Fields = values
+	return row
+}
+
+func setField(fields map[string]interface{}, path []string, value string) {
+	for _, key := range path[:len(path)-1] {
+		nested, ok := fields[key].(map[string]interface{})
+		if !ok {
+			nested = make(map[string]interface{})
+			fields[key] = nested
+		}
+		fields = nested
+	}
+	fields[path[len(path)-1]] = value
+}
+
+func (im *Importer) run(ctx context.Context, next func() (*ImportRow, error)) (*ImportSummary, error) {
+	if im.KYC == nil || im.Log == "" {
+		return nil, errors.New("kyc or log is empty")
+	}
+	done, terminated, err := readImportLog(im.Log)
+	if err != nil {
+		return nil, err
+	}
+	log, err := os.OpenFile(im.Log, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer log.Close()
+	if !terminated {
+		if _, err = log.Write([]byte("\n")); err != nil {
+			return nil, err
+		}
+	}
+
+	kyc := im.KYC.WithContext(ctx)
+	summary := &ImportSummary{}
+	encoder := json.NewEncoder(log)
+	for {
+		if err = ctx.Err(); err != nil {
+			return summary, err
+		}
+		row, err := next()
+		if err == io.EOF {
+			return summary, nil
+		}
+		if err != nil {
+			return summary, err
+		}
+
+		result := &ImportResult{
+			Line:       row.Line,
+			Type:       row.Type,
+			Identifier: row.Identifier,
+			Hash:       row.hash(),
+		}
+		if done[importKey(result)] {
+			summary.Skipped++
+			continue
+		}
+
+		if row.err != nil {
+			result.Action, err = ImportFailed, row.err
+		} else {
+			result.ApplicationID, result.Action, err = importRow(kyc, row)
+		}
+		if err != nil {
+			result.Action = ImportFailed
+			result.Error = err.Error()
+		}
+		result.Time = time.Now().UTC()
+
+		switch result.Action {
+		case ImportCreated:
+			summary.Created++
+		case ImportUpdated:
+			summary.Updated++
+		default:
+			summary.Failed++
+		}
+		summary.Results = append(summary.Results, result)
+		if err = encoder.Encode(result); err != nil {
+			return summary, fmt.Errorf("write import log failed: %v", err)
+		}
+	}
+}
+
+func importRow(kyc *KYC, row *ImportRow) (string, string, error) {
+	if row.Type == "" || row.Identifier == "" {
+		return "", ImportFailed, errors.New("type or identifier is empty")
+	}
+
+	action := ImportUpdated
+	id, err := findApplication(kyc, row.Type, row.Identifier)
+	if err != nil {
+		return "", ImportFailed, err
+	}
+	if id == "" {
+		ret, err := checkResult(kyc.ApplicationCreate(row.Type, row.Identifier, row.Operator))
+		if err != nil {
+			return "", ImportFailed, err
+		}
+		action = ImportCreated
+		if id = applicationID(ret.Data); id == "" {
+			return "", ImportFailed, errors.New("application id is missing in the result")
+		}
+	}
+
+	if len(row.Fields) > 0 {
+		if _, err = checkResult(kyc.ApplicationUpdate2(id, row.Fields)); err != nil {
+			return id, ImportFailed, err
+		}
+	}
+	return id, action, nil
+}
+
+// findApplication returns the id of the application with the identifier, or
+// an empty id if the server reports it does not exist, see WithNotFoundCodes.
+// Any other error is returned.
+func findApplication(kyc *KYC, mType, identifier string) (string, error) {
+	ret, err := checkResult(kyc.ApplicationGetByIdentifier(mType, identifier, false))
+	if IsNotFound(err, kyc.session.notFoundCodes...) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return applicationID(ret.Data), nil
+}
+
+// hash identifies the content of the row, a changed row is imported again.
+func (row *ImportRow) hash() string {
+	buf, _ := json.Marshal([]interface{}{row.Operator, row.Fields})
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+func importKey(result *ImportResult) string {
+	return result.Type + "/" + result.Identifier + "/" + result.Hash
+}
+
+// readImportLog returns the rows already imported, lines which cannot be
+// parsed, such as one truncated by a crash, are ignored. terminated is false
+// if the log does not end with a newline.
+func readImportLog(path string) (done map[string]bool, terminated bool, err error) {
+	done = make(map[string]bool)
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return done, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, line := range bytes.Split(buf, []byte("\n")) {
+		var result ImportResult
+		if json.Unmarshal(line, &result) != nil {
+			continue
+		}
+		if result.Action == ImportCreated || result.Action == ImportUpdated {
+			done[importKey(&result)] = true
+		}
+	}
+	return done, len(buf) == 0 || buf[len(buf)-1] == '\n', nil
+}
@@ -0,0 +1,150 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestImporter(t *testing.T) {
+	var mu sync.Mutex
+	ids := map[string]string{"individual/alice": "a1"}
+	patches := make(map[string]map[string]interface{})
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		data := map[string]interface{}{}
+		switch {
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/api/v1/application/identifier/"):
+			id, ok := ids[strings.TrimPrefix(r.URL.Path, "/api/v1/application/identifier/")]
+			if !ok {
+				writeErrorResponse(w, 404, "application not found")
+				return
+			}
+			data["id"] = id
+		case r.Method == "POST" && r.URL.Path == "/api/v1/application":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			id := "new-" + body["identifier"].(string)
+			ids[body["type"].(string)+"/"+body["identifier"].(string)] = id
+			data["id"] = id
+		case r.Method == "PATCH":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			delete(body, "timestamp")
+			delete(body, "nonce")
+			delete(body, "sign")
+			patches[strings.TrimPrefix(r.URL.Path, "/api/v1/application/")] = body
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		_, err := writeSuccessResponse(w, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	importer := &Importer{
+		KYC:      NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret, WithNotFoundCodes(404)),
+		Log:      filepath.Join(dir, "import.log"),
+		Type:     ApplicationTypeIndividual,
+		Operator: "migration",
+	}
+	input := "identifier,firstName,address.city\nalice,Alice,London\nbob,Bob,\n,Nobody,Paris\ncarol,Carol,Paris,extra\ndave,Dave,\n"
+	summary, err := importer.ImportCSV(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Created != 2 || summary.Updated != 1 || summary.Failed != 2 || summary.Skipped != 0 {
+		t.Errorf("summary = %+v; want 2 created, 1 updated, 2 failed", summary)
+	}
+	if r := summary.Results[3]; r.Line != 5 || r.Action != ImportFailed || r.Error == "" {
+		t.Errorf("result = %+v; want line 5 failed", r)
+	}
+	address, _ := patches["a1"]["address"].(map[string]interface{})
+	if patches["a1"]["firstName"] != "Alice" || address["city"] != "London" {
+		t.Errorf("patch of a1 = %v; want firstName and address.city", patches["a1"])
+	}
+	if _, ok := patches["new-bob"]["address"]; ok {
+		t.Errorf("patch of new-bob = %v; want empty cells ignored", patches["new-bob"])
+	}
+
+	// a crash left a truncated line in the log.
+	f, _ := os.OpenFile(importer.Log, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString(`{"line":9,"type":"indiv`)
+	f.Close()
+
+	patches = make(map[string]map[string]interface{})
+	jsonl := `{"identifier":"alice","firstName":"Alice","address":{"city":"London"}}
+{"identifier":"carol",
+{"identifier":"bob","firstName":"Robert"}
+`
+	summary, err = importer.ImportJSONL(context.Background(), strings.NewReader(jsonl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Skipped != 1 || summary.Updated != 1 || summary.Failed != 1 || len(patches) != 1 || patches["new-bob"]["firstName"] != "Robert" {
+		t.Errorf("summary = %+v, patches = %v; want alice skipped, line 2 failed and bob updated", summary, patches)
+	}
+
+	buf, _ := ioutil.ReadFile(importer.Log)
+	lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("log = %s; want 8 lines", buf)
+	}
+	var last ImportResult
+	if err = json.Unmarshal([]byte(lines[7]), &last); err != nil || last.Identifier != "bob" || last.Action != ImportUpdated {
+		t.Errorf("last log line = %s, %v; want bob updated", lines[7], err)
+	}
+}
+
+func TestFindApplication(t *testing.T) {
+	var code, status int
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+		writeErrorResponse(w, code, "error")
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret, WithNotFoundCodes(1004))
+	for _, c := range []struct {
+		code, status int
+		fails        bool
+	}{
+		{code: 1004, fails: false},
+		{code: 404, fails: true},
+		{status: http.StatusNotFound, fails: false},
+		{code: 401, fails: true},
+		{status: http.StatusTooManyRequests, fails: true},
+	} {
+		code, status = c.code, c.status
+		id, err := findApplication(kyc, ApplicationTypeIndividual, "alice")
+		if id != "" || (err != nil) != c.fails {
+			t.Errorf("code %d, status %d: findApplication = %q, %v; want error %v", c.code, c.status, id, err, c.fails)
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package jadepoolsaas
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Jumio verification statuses.
+const (
+	JumioApprovedVerified         = "APPROVED_VERIFIED"
+	JumioDeniedFraud              = "DENIED_FRAUD"
+	JumioDeniedUnsupportedIDType  = "DENIED_UNSUPPORTED_ID_TYPE"
+	JumioDeniedUnsupportedCountry = "DENIED_UNSUPPORTED_ID_COUNTRY"
+	JumioErrorNotReadableID       = "ERROR_NOT_READABLE_ID"
+	JumioNoIDUploaded             = "NO_ID_UPLOADED"
+)
+
+// Jumio similarity results of the identity verification.
+const (
+	JumioMatch       = "MATCH"
+	JumioNoMatch     = "NO_MATCH"
+	JumioNotPossible = "NOT_POSSIBLE"
+)
+
+// JumioSession is the jumio verification session of an application.
+type JumioSession struct {
+	Timestamp            string `json:"timestamp,omitempty"`
+	TransactionReference string `json:"transactionReference"`
+	RedirectURL          string `json:"redirectUrl"`
+}
+
+// JumioRejectDetail details a reject reason.
+type JumioRejectDetail struct {
+	Code        string `json:"detailsCode"`
+	Description string `json:"detailsDescription"`
+}
+
+// JumioRejectReason is the reason a document was rejected.
+type JumioRejectReason struct {
+	Code        string              `json:"rejectReasonCode"`
+	Description string              `json:"rejectReasonDescription"`
+	Details     []JumioRejectDetail `json:"rejectReasonDetails,omitempty"`
+}
+
+// UnmarshalJSON accepts the reason as an object or as a json encoded string,
+// as it is sent in form callbacks.
+func (r *JumioRejectReason) UnmarshalJSON(data []byte) error {
+	type plain JumioRejectReason
+	return unmarshalJumioObject(data, (*plain)(r))
+}
+
+// JumioIdentityVerification is the result comparing the face with the document.
+type JumioIdentityVerification struct {
+	Similarity string `json:"similarity"`
+	// Validity is false if the face capture is not a live person.
+	Validity JumioBool `json:"validity"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// JumioBool is a boolean jumio sends as the string "TRUE" or "FALSE".
+type JumioBool bool
+
+// MarshalJSON encodes the boolean as jumio does.
+func (b JumioBool) MarshalJSON() ([]byte, error) {
+	if b {
+		return []byte(`"TRUE"`), nil
+	}
+	return []byte(`"FALSE"`), nil
+}
+
+// UnmarshalJSON accepts the "TRUE" and "FALSE" strings, in any case, and
+// json booleans.
+func (b *JumioBool) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return json.Unmarshal(data, (*bool)(b))
+	}
+	switch strings.ToUpper(text) {
+	case "TRUE":
+		*b = true
+	case "FALSE", "":
+		*b = false
+	default:
+		return fmt.Errorf("invalid jumio boolean %q", text)
+	}
+	return nil
+}
+
+// UnmarshalJSON accepts the verification as an object or as a json encoded
+// string, as it is sent in form callbacks.
+func (v *JumioIdentityVerification) UnmarshalJSON(data []byte) error {
+	type plain JumioIdentityVerification
+	return unmarshalJumioObject(data, (*plain)(v))
+}
+
+func unmarshalJumioObject(data []byte, v interface{}) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		if text == "" {
+			return nil
+		}
+		data = []byte(text)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// JumioCallback is the verification result jumio sends to the callback url.
+type JumioCallback struct {
+	ScanReference        string                     `json:"jumioIdScanReference"`
+	MerchantReference    string                     `json:"merchantIdScanReference,omitempty"`
+	CustomerID           string                     `json:"customerId,omitempty"`
+	VerificationStatus   string                     `json:"verificationStatus"`
+	IDScanStatus         string                     `json:"idScanStatus"`
+	IDType               string                     `json:"idType,omitempty"`
+	IDSubtype            string                     `json:"idSubtype,omitempty"`
+	IDCountry            string                     `json:"idCountry,omitempty"`
+	IDNumber             string                     `json:"idNumber,omitempty"`
+	IDFirstName          string                     `json:"idFirstName,omitempty"`
+	IDLastName           string                     `json:"idLastName,omitempty"`
+	IDDateOfBirth        string                     `json:"idDob,omitempty"`
+	IDExpiry             string                     `json:"idExpiry,omitempty"`
+	TransactionDate      string                     `json:"transactionDate,omitempty"`
+	CallbackDate         string                     `json:"callbackDate,omitempty"`
+	IdentityVerification *JumioIdentityVerification `json:"identityVerification,omitempty"`
+	RejectReason         *JumioRejectReason         `json:"rejectReason,omitempty"`
+}
+
+// ParseJumioCallback reads the callback from a request sent by jumio, in
+// form or json encoding.
+func ParseJumioCallback(r *http.Request) (*JumioCallback, error) {
+	_, callback, err := parseJumioPayload(r)
+	return callback, err
+}
+
+// parseJumioPayload reads the fields of a callback as received, and decodes
+// them into the typed callback. Form fields are kept as strings, except json
+// objects and lists such as rejectReason which are decoded.
+func parseJumioPayload(r *http.Request) (map[string]interface{}, *JumioCallback, error) {
+	payload := make(map[string]interface{})
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			return nil, nil, fmt.Errorf("parse jumio callback failed: %v", err)
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return nil, nil, fmt.Errorf("parse jumio callback failed: %v", err)
+		}
+		for key, values := range r.PostForm {
+			if len(values) == 0 {
+				continue
+			}
+			payload[key] = values[0]
+			if trimmed := strings.TrimSpace(values[0]); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+				var value interface{}
+				if json.Unmarshal([]byte(trimmed), &value) == nil {
+					payload[key] = value
+				}
+			}
+		}
+	}
+
+	callback := &JumioCallback{}
+	if err := decodeData(payload, callback); err != nil {
+		return nil, nil, fmt.Errorf("parse jumio callback failed: %v", err)
+	}
+	return payload, callback, nil
+}
+
+var jumioVerificationStatuses = []string{
+	JumioApprovedVerified,
+	JumioDeniedFraud,
+	JumioDeniedUnsupportedIDType,
+	JumioDeniedUnsupportedCountry,
+	JumioErrorNotReadableID,
+	JumioNoIDUploaded,
+}
+
+// Validate checks the fields of the callback.
+func (c *JumioCallback) Validate() error {
+	v := newValidator()
+	v.required("jumioIdScanReference", c.ScanReference)
+	if v.required("verificationStatus", c.VerificationStatus) && !containsString(jumioVerificationStatuses, c.VerificationStatus) {
+		v.add("verificationStatus", "unknown verification status "+c.VerificationStatus)
+	}
+	if c.IDScanStatus != "" && c.IDScanStatus != "SUCCESS" && c.IDScanStatus != "ERROR" {
+		v.add("idScanStatus", "must be SUCCESS or ERROR")
+	}
+	if c.IdentityVerification != nil && c.IdentityVerification.Similarity != "" {
+		similarity := c.IdentityVerification.Similarity
+		if similarity != JumioMatch && similarity != JumioNoMatch && similarity != JumioNotPossible {
+			v.add("identityVerification.similarity", "unknown similarity "+similarity)
+		}
+	}
+	return v.err()
+}
+
+// JumioOutcome is the application status implied by a jumio verification,
+// with the reasons to show to the applicant.
+type JumioOutcome struct {
+	Status  ApplicationStatus `json:"status"`
+	Reasons []string          `json:"reasons,omitempty"`
+}
+
+var jumioStatusReasons = map[string]string{
+	JumioDeniedFraud:              "the document was identified as fraudulent",
+	JumioDeniedUnsupportedIDType:  "the document type is not supported",
+	JumioDeniedUnsupportedCountry: "documents of the issuing country are not supported",
+	JumioErrorNotReadableID:       "the document is not readable",
+	JumioNoIDUploaded:             "no document was uploaded",
+}
+
+// Outcome maps the verification to an application status. Fraud is
+// rejected, a verified document matching a live face is approved, and every
+// other result needs the applicant to verify again.
+func (c *JumioCallback) Outcome() *JumioOutcome {
+	outcome := &JumioOutcome{Status: StatusNeedsInfo}
+	if reason, ok := jumioStatusReasons[c.VerificationStatus]; ok {
+		outcome.Reasons = append(outcome.Reasons, reason)
+	}
+	if r := c.RejectReason; r != nil {
+		if r.Description != "" {
+			outcome.Reasons = append(outcome.Reasons, r.Description)
+		} else if r.Code != "" {
+			outcome.Reasons = append(outcome.Reasons, "reject reason "+r.Code)
+		}
+		for _, detail := range r.Details {
+			if detail.Description != "" {
+				outcome.Reasons = append(outcome.Reasons, detail.Description)
+			}
+		}
+	}
+
+	switch c.VerificationStatus {
+	case JumioDeniedFraud:
+		outcome.Status = StatusRejected
+	case JumioApprovedVerified:
+		iv := c.IdentityVerification
+		if iv != nil && iv.Similarity == "" {
+			iv = nil
+		}
+		switch {
+		case iv == nil || iv.Similarity == JumioMatch && bool(iv.Validity):
+			outcome.Status = StatusApproved
+		case iv.Similarity == JumioNoMatch:
+			outcome.Reasons = append(outcome.Reasons, "the face does not match the document")
+		case iv.Similarity == JumioNotPossible:
+			outcome.Reasons = append(outcome.Reasons, "the face could not be compared with the document")
+		default:
+			outcome.Reasons = append(outcome.Reasons, "the face capture is not a live person")
+		}
+		if iv != nil && iv.Reason != "" && outcome.Status != StatusApproved {
+			outcome.Reasons = append(outcome.Reasons, iv.Reason)
+		}
+	}
+	return outcome
+}
+
+// ApplicationJumioSession get the jumio verification session of the application.
+func (k *KYC) ApplicationJumioSession(applicationID, locale, id string) (*JumioSession, error) {
+	if len(applicationID) == 0 {
+		return nil, errors.New("applicationID is empty")
+	}
+
+	ret, err := checkResult(k.ApplicationJumioGet(applicationID, locale, id))
+	if err != nil {
+		return nil, err
+	}
+	session := &JumioSession{}
+	if err = ret.Decode(session); err != nil {
+		return nil, fmt.Errorf("parse jumio session failed: %v", err)
+	}
+	return session, nil
+}
+
+// JumioForwardPayload validates the callback payload, as received from
+// jumio, forwards it unchanged with JumioPost and returns its outcome. Fields
+// not modeled by JumioCallback are forwarded too.
+func (k *KYC) JumioForwardPayload(applicationID string, payload map[string]interface{}) (*JumioOutcome, error) {
+	if len(applicationID) == 0 {
+		return nil, errors.New("applicationID is empty")
+	}
+	callback := &JumioCallback{}
+	if err := decodeData(payload, callback); err != nil {
+		return nil, fmt.Errorf("parse jumio callback failed: %v", err)
+	}
+	if err := callback.Validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := checkResult(k.JumioPost(applicationID, payload)); err != nil {
+		return nil, err
+	}
+	return callback.Outcome(), nil
+}
+
+// JumioForward validates the callback, forwards its fields with JumioPost
+// and returns its outcome. Use JumioForwardPayload to forward the fields
+// jumio sent which JumioCallback does not model.
+func (k *KYC) JumioForward(applicationID string, callback *JumioCallback) (*JumioOutcome, error) {
+	if len(applicationID) == 0 {
+		return nil, errors.New("applicationID is empty")
+	}
+	if err := callback.Validate(); err != nil {
+		return nil, err
+	}
+
+	content := make(map[string]interface{})
+	if err := decodeData(callback, &content); err != nil {
+		return nil, err
+	}
+	if _, err := checkResult(k.JumioPost(applicationID, content)); err != nil {
+		return nil, err
+	}
+	return callback.Outcome(), nil
+}
+
+// maxJumioCallbackSize limits the body of a callback read for verification.
+const maxJumioCallbackSize = 1 << 20
+
+// JumioCallbackHandler returns a handler receiving jumio callbacks and
+// forwarding them as received with JumioForwardPayload. Every
+// request is first checked by verify, such as JumioSourceVerifier, and
+// answered with 403 if it fails, the body can be read by verify and is
+// restored afterwards. The application is found by applicationID from the
+// callback, such as its merchant reference. Invalid callbacks and callbacks
+// of unknown applications are answered with 400 and failed forwards with 502
+// so that jumio retries them. verify must not be nil.
+func (k *KYC) JumioCallbackHandler(verify func(*http.Request) error, applicationID func(*JumioCallback) string, onOutcome func(applicationID string, outcome *JumioOutcome)) http.Handler {
+	if verify == nil {
+		panic("jumio callback verify is nil")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxJumioCallbackSize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err = verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		payload, callback, err := parseJumioPayload(r)
+		if err == nil {
+			err = callback.Validate()
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		id := applicationID(callback)
+		if id == "" {
+			http.Error(w, "unknown application of the callback", http.StatusBadRequest)
+			return
+		}
+		outcome, err := k.WithContext(r.Context()).JumioForwardPayload(id, payload)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		if onOutcome != nil {
+			onOutcome(id, outcome)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// JumioSourceVerifier returns a verify function for JumioCallbackHandler
+// accepting requests from the networks in cidrs, such as the callback ips
+// published by jumio. The address of the connection is used, set it from a
+// trusted proxy header before the handler if needed.
+func JumioSourceVerifier(cidrs ...string) (func(*http.Request) error, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+
+	return func(r *http.Request) error {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		ip := net.ParseIP(host)
+		for _, network := range networks {
+			if ip != nil && network.Contains(ip) {
+				return nil
+			}
+		}
+		return fmt.Errorf("callback from %s is not allowed", host)
+	}, nil
+}
@@ -0,0 +1,132 @@
+package jadepoolsaas
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestJumioOutcome(t *testing.T) {
+	for _, test := range []struct {
+		callback JumioCallback
+		status   ApplicationStatus
+		reasons  int
+	}{
+		{JumioCallback{VerificationStatus: JumioApprovedVerified, IdentityVerification: &JumioIdentityVerification{Similarity: JumioMatch, Validity: true}}, StatusApproved, 0},
+		{JumioCallback{VerificationStatus: JumioApprovedVerified}, StatusApproved, 0},
+		{JumioCallback{VerificationStatus: JumioApprovedVerified, IdentityVerification: &JumioIdentityVerification{Similarity: JumioNoMatch}}, StatusNeedsInfo, 1},
+		{JumioCallback{VerificationStatus: JumioDeniedFraud, RejectReason: &JumioRejectReason{Code: "106", Description: "FAKE"}}, StatusRejected, 2},
+		{JumioCallback{VerificationStatus: JumioErrorNotReadableID}, StatusNeedsInfo, 1},
+	} {
+		outcome := test.callback.Outcome()
+		if outcome.Status != test.status || len(outcome.Reasons) != test.reasons {
+			t.Errorf("outcome of %+v = %+v; want %s with %d reasons", test.callback, outcome, test.status, test.reasons)
+		}
+	}
+}
+
+func TestJumioCallbackHandler(t *testing.T) {
+	var body map[string]interface{}
+	var forwards int
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		forwards++
+		if r.Method != "POST" || r.URL.Path != "/api/v1/application/a1/jumio" {
+			t.Errorf("request = %s %s; want jumio post", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		_, err := writeSuccessResponse(w, map[string]interface{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret)
+	var outcome *JumioOutcome
+	verify, err := JumioSourceVerifier("192.0.2.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := kyc.JumioCallbackHandler(verify, func(c *JumioCallback) string {
+		return c.MerchantReference
+	}, func(applicationID string, o *JumioOutcome) {
+		outcome = o
+	})
+
+	form := url.Values{
+		"jumioIdScanReference":    {"scan-1"},
+		"merchantIdScanReference": {"a1"},
+		"verificationStatus":      {JumioDeniedFraud},
+		"idScanStatus":            {"ERROR"},
+		"personalNumber":          {"P-123"},
+		"rejectReason":            {`{"rejectReasonCode":"100","rejectReasonDescription":"MANIPULATED_DOCUMENT","rejectReasonDetails":[{"detailsCode":"1001","detailsDescription":"PHOTO"}]}`},
+	}
+	r := httptest.NewRequest("POST", "/jumio", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("code = %d, %s; want 200", w.Code, w.Body)
+	}
+	if outcome == nil || outcome.Status != StatusRejected || len(outcome.Reasons) != 3 {
+		t.Errorf("outcome = %+v; want rejected with 3 reasons", outcome)
+	}
+	reason, _ := body["rejectReason"].(map[string]interface{})
+	if reason["rejectReasonCode"] != "100" {
+		t.Errorf("body = %v; want forwarded reject reason", body)
+	}
+	if body["personalNumber"] != "P-123" {
+		t.Errorf("body = %v; want forwarded personal number", body)
+	}
+
+	approved := url.Values{
+		"jumioIdScanReference":    {"scan-2"},
+		"merchantIdScanReference": {"a1"},
+		"verificationStatus":      {JumioApprovedVerified},
+		"idScanStatus":            {"SUCCESS"},
+		"identityVerification":    {`{"similarity":"MATCH","validity":"TRUE"}`},
+	}
+	r = httptest.NewRequest("POST", "/jumio", strings.NewReader(approved.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("code = %d, %s; want 200", w.Code, w.Body)
+	}
+	if outcome.Status != StatusApproved {
+		t.Errorf("outcome = %+v; want approved", outcome)
+	}
+	verification, _ := body["identityVerification"].(map[string]interface{})
+	if verification["validity"] != "TRUE" {
+		t.Errorf("body = %v; want forwarded validity TRUE", body)
+	}
+
+	forwards = 0
+	for _, test := range []struct {
+		remoteAddr string
+		form       url.Values
+		code       int
+	}{
+		{"203.0.113.9:4000", approved, http.StatusForbidden},
+		{"192.0.2.1:1234", url.Values{"jumioIdScanReference": {"scan-3"}, "verificationStatus": {JumioApprovedVerified}}, http.StatusBadRequest},
+		{"192.0.2.1:1234", url.Values{"jumioIdScanReference": {"scan-4"}, "merchantIdScanReference": {"a1"}, "verificationStatus": {"UNKNOWN"}}, http.StatusBadRequest},
+	} {
+		r = httptest.NewRequest("POST", "/jumio", strings.NewReader(test.form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.RemoteAddr = test.remoteAddr
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("code of %s %v = %d; want %d", test.remoteAddr, test.form, w.Code, test.code)
+		}
+	}
+	if forwards != 0 {
+		t.Errorf("forwards = %d; want none of refused callbacks", forwards)
+	}
+}
@@ -0,0 +1,381 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// OnboardingDocument is a document uploaded during onboarding.
+type OnboardingDocument struct {
+	// Name is the file name, unique within the onboarding.
+	Name string
+	// Type is the kind of document, such as passport or proofOfAddress.
+	Type string
+	// Open returns the content of the document, it is closed once uploaded.
+	Open func() (io.ReadCloser, error)
+}
+
+// OnboardingState is the persisted progress of an onboarding.
+type OnboardingState struct {
+	ApplicationID string            `json:"applicationID"`
+	Files         map[string]string `json:"files"`
+	ContentSaved  bool              `json:"contentSaved"`
+	FiatIDs       []string          `json:"fiatIDs"`
+	Submitted     bool              `json:"submitted"`
+	Status        ApplicationStatus `json:"status"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+	// Uploading is the document being uploaded, set until its id is saved.
+	Uploading string `json:"uploading,omitempty"`
+}
+
+// Onboarding runs the steps onboarding a customer as a resumable state
+// machine: create the application, upload documents, save the content,
+// create fiat accounts, submit and poll the review status. The progress is
+// persisted to Store after every step, so running it again skips the steps
+// already completed. As with Importer, the application is created if the
+// lookup by identifier reports it missing, see WithNotFoundCodes.
+type Onboarding struct {
+	KYC   *KYC
+	Store Store
+
+	// Type is ApplicationTypeIndividual or ApplicationTypeCorporate.
+	Type       string
+	Identifier string
+	Operator   string
+	Content    ApplicationContent
+	Documents  []OnboardingDocument
+	Fiats      []map[string]interface{}
+
+	// PollInterval is the period polling the review status after submission,
+	// 0 returns right after submission.
+	PollInterval time.Duration
+	// OnStatusChange is called after every status transition.
+	OnStatusChange func(state *OnboardingState, from, to ApplicationStatus)
+}
+
+// Key returns the key of the onboarding state in the store.
+func (o *Onboarding) Key() string {
+	return "onboarding/" + o.Type + "/" + o.Identifier
+}
+
+// Run runs the remaining steps of the onboarding. It returns once the review
+// ended or needs more information, or right after submission if PollInterval
+// is 0. When more information is needed, update Content and run it again to
+// save and submit the application again.
+func (o *Onboarding) Run(ctx context.Context) (*OnboardingState, error) {
+	if o.KYC == nil || o.Store == nil {
+		return nil, errors.New("kyc or store is nil")
+	}
+	if len(o.Type) == 0 || len(o.Identifier) == 0 {
+		return nil, errors.New("type or identifier is empty")
+	}
+	if o.Content != nil {
+		if err := o.Content.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	state := &OnboardingState{}
+	if _, err := o.Store.Load(o.Key(), state); err != nil {
+		return nil, err
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]string)
+	}
+	if state.Status.Final() {
+		return state, nil
+	}
+
+	kyc := o.KYC.WithContext(ctx)
+	steps := []func(context.Context, *KYC, *OnboardingState) error{
+		o.create,
+		o.upload,
+		o.saveContent,
+		o.createFiats,
+		o.submit,
+	}
+	for _, step := range steps {
+		if err := step(ctx, kyc, state); err != nil {
+			return state, err
+		}
+	}
+
+	if o.PollInterval <= 0 {
+		return state, nil
+	}
+	return state, o.poll(ctx, kyc, state)
+}
+
+func (o *Onboarding) create(ctx context.Context, kyc *KYC, state *OnboardingState) error {
+	if state.ApplicationID != "" {
+		return nil
+	}
+
+	// the application may have been created by a run which stopped before
+	// saving the state
+	id, err := findApplication(kyc, o.Type, o.Identifier)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		ret, err := checkResult(kyc.ApplicationCreate(o.Type, o.Identifier, o.Operator))
+		if err != nil {
+			return err
+		}
+		if id = applicationID(ret.Data); id == "" {
+			return errors.New("application id is missing in the result")
+		}
+	}
+	state.ApplicationID = id
+	return o.setStatus(state, StatusDraft)
+}
+
+func (o *Onboarding) upload(ctx context.Context, kyc *KYC, state *OnboardingState) error {
+	for _, doc := range o.Documents {
+		if _, ok := state.Files[doc.Name]; ok {
+			continue
+		}
+
+		// the document may have been uploaded by a run which stopped before
+		// saving its id
+		if state.Uploading == doc.Name {
+			id, err := uploadedFile(kyc, state, doc.Name)
+			if err != nil {
+				return err
+			}
+			if id != "" {
+				state.Files[doc.Name] = id
+				state.Uploading = ""
+				state.ContentSaved = false
+				if err = o.save(state); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		state.Uploading = doc.Name
+		if err := o.save(state); err != nil {
+			return err
+		}
+
+		r, err := doc.Open()
+		if err != nil {
+			return fmt.Errorf("open document %s failed: %v", doc.Name, err)
+		}
+		info, err := kyc.UploadFile(ctx, state.ApplicationID, doc.Name, r, -1)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("upload document %s failed: %v", doc.Name, err)
+		}
+
+		state.Files[doc.Name] = info.ID
+		state.Uploading = ""
+		state.ContentSaved = false
+		if err = o.save(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *Onboarding) saveContent(ctx context.Context, kyc *KYC, state *OnboardingState) error {
+	if state.ContentSaved {
+		return nil
+	}
+
+	fields := make(map[string]interface{})
+	if o.Content != nil {
+		var err error
+		if fields, err = contentFields(o.Content); err != nil {
+			return err
+		}
+	}
+	if len(o.Documents) > 0 {
+		var documents []interface{}
+		if existing, ok := fields["documents"].([]interface{}); ok {
+			documents = existing
+		}
+		for _, doc := range o.Documents {
+			documents = append(documents, map[string]interface{}{
+				"type":   doc.Type,
+				"fileID": state.Files[doc.Name],
+			})
+		}
+		fields["documents"] = documents
+	}
+
+	if len(fields) > 0 {
+		if _, err := checkResult(kyc.ApplicationUpdate2(state.ApplicationID, fields)); err != nil {
+			return err
+		}
+	}
+	state.ContentSaved = true
+	return o.save(state)
+}
+
+func (o *Onboarding) createFiats(ctx context.Context, kyc *KYC, state *OnboardingState) error {
+	if len(state.FiatIDs) >= len(o.Fiats) {
+		return nil
+	}
+	existing, err := o.unsavedFiats(kyc, state)
+	if err != nil {
+		return err
+	}
+
+	for i := len(state.FiatIDs); i < len(o.Fiats); i++ {
+		id := matchFiat(existing, o.Fiats[i])
+		if id == "" {
+			ret, err := checkResult(kyc.FiatCreate(state.ApplicationID, o.Fiats[i]))
+			if err != nil {
+				return err
+			}
+			if id, _ = ret.Data["id"].(string); id == "" {
+				return errors.New("fiat id is missing in the result")
+			}
+		}
+
+		state.FiatIDs = append(state.FiatIDs, id)
+		if err = o.save(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadedFile returns the id of the last file of the application with the
+// name which is not saved in the state, or an empty id if there is none.
+func uploadedFile(kyc *KYC, state *OnboardingState, name string) (string, error) {
+	ret, err := checkResult(kyc.ApplicationGet(state.ApplicationID, true))
+	if err != nil {
+		return "", err
+	}
+
+	saved := make(map[string]bool)
+	for _, id := range state.Files {
+		saved[id] = true
+	}
+	id := ""
+	for _, item := range dataList(ret.Data, "files") {
+		file, _ := item.(map[string]interface{})
+		if fileID := stringField(file, "id"); stringField(file, "name") == name && fileID != "" && !saved[fileID] {
+			id = fileID
+		}
+	}
+	return id, nil
+}
+
+// unsavedFiats returns the fiat accounts of the application missing in the
+// state, created by a run which stopped before saving it.
+func (o *Onboarding) unsavedFiats(kyc *KYC, state *OnboardingState) (map[string]map[string]interface{}, error) {
+	ret, err := checkResult(kyc.FiatsGet(state.ApplicationID))
+	if err != nil {
+		return nil, err
+	}
+
+	fiats := make(map[string]map[string]interface{})
+	for _, item := range fiatList(ret.Data) {
+		fiat, _ := item.(map[string]interface{})
+		id := stringField(fiat, "id")
+		if id != "" && !containsString(state.FiatIDs, id) {
+			fiats[id] = fiat
+		}
+	}
+	return fiats, nil
+}
+
+// matchFiat returns and removes the id of an existing fiat account having
+// every field of fiat, or an empty id if none has.
+func matchFiat(existing map[string]map[string]interface{}, fiat map[string]interface{}) string {
+	var want map[string]interface{}
+	if err := decodeData(fiat, &want); err != nil {
+		return ""
+	}
+
+	ids := make([]string, 0, len(existing))
+	for id := range existing {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		matched := true
+		for key, value := range want {
+			if !reflect.DeepEqual(existing[id][key], value) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			delete(existing, id)
+			return id
+		}
+	}
+	return ""
+}
+
+func (o *Onboarding) submit(ctx context.Context, kyc *KYC, state *OnboardingState) error {
+	if state.Submitted {
+		return nil
+	}
+
+	if _, err := checkResult(kyc.ApplicationSubmit(state.ApplicationID)); err != nil {
+		return err
+	}
+	state.Submitted = true
+	return o.setStatus(state, StatusSubmitted)
+}
+
+func (o *Onboarding) poll(ctx context.Context, kyc *KYC, state *OnboardingState) error {
+	ticker := time.NewTicker(o.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		ret, err := checkResult(kyc.ApplicationGet(state.ApplicationID, false))
+		if err != nil {
+			return err
+		}
+
+		status := applicationStatus(ret.Data)
+		if status == "" {
+			status = state.Status
+		}
+		if status == StatusNeedsInfo {
+			state.ContentSaved = false
+			state.Submitted = false
+		}
+		if err = o.setStatus(state, status); err != nil {
+			return err
+		}
+		if status.Final() || status == StatusNeedsInfo {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (o *Onboarding) setStatus(state *OnboardingState, status ApplicationStatus) error {
+	from := state.Status
+	state.Status = status
+	if err := o.save(state); err != nil {
+		return err
+	}
+
+	if from != status && o.OnStatusChange != nil {
+		o.OnStatusChange(state, from, status)
+	}
+	return nil
+}
+
+func (o *Onboarding) save(state *OnboardingState) error {
+	state.UpdatedAt = time.Now()
+	return o.Store.Save(o.Key(), state)
+}
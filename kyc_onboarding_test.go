@@ -0,0 +1,219 @@
+package jadepoolsaas
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOnboarding(t *testing.T) {
+	calls := make(map[string]int)
+	fiatFailures := 1
+	status := "pending"
+
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		route := r.Method + " " + r.URL.Path
+		calls[route]++
+
+		response := map[string]interface{}{}
+		switch route {
+		case "POST /api/v1/application":
+			response["id"] = "a1"
+		case "POST /api/v1/file":
+			response["id"] = "f" + r.FormValue("applicationID")
+		case "POST /api/v1/application/a1/fiat":
+			if fiatFailures > 0 {
+				fiatFailures--
+				writeErrorResponse(w, 500, "bank unavailable")
+				return
+			}
+			response["id"] = "fiat1"
+		case "GET /api/v1/application/a1":
+			response["status"] = status
+			status = "approved"
+		}
+
+		_, err := writeSuccessResponse(w, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	var transitions []ApplicationStatus
+	store := NewMemoryStore()
+	onboarding := &Onboarding{
+		KYC:        NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret),
+		Store:      store,
+		Type:       ApplicationTypeIndividual,
+		Identifier: "u1",
+		Content:    testIndividualApplication(),
+		Documents: []OnboardingDocument{
+			{Name: "passport.jpg", Type: "passport", Open: func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(bytes.NewReader([]byte("passport"))), nil
+			}},
+		},
+		Fiats:        []map[string]interface{}{{"currency": "USD"}},
+		PollInterval: 1,
+		OnStatusChange: func(state *OnboardingState, from, to ApplicationStatus) {
+			transitions = append(transitions, to)
+		},
+	}
+
+	state, err := onboarding.Run(context.Background())
+	if err == nil {
+		t.Fatal("want fiat creation error")
+	}
+	if state.ApplicationID != "a1" || state.Files["passport.jpg"] != "fa1" || !state.ContentSaved || state.Submitted {
+		t.Errorf("state = %+v; want stopped before submission", state)
+	}
+
+	state, err = onboarding.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != StatusApproved || len(state.FiatIDs) != 1 {
+		t.Errorf("state = %+v; want approved with one fiat", state)
+	}
+
+	for route, want := range map[string]int{
+		"POST /api/v1/application":         1,
+		"POST /api/v1/file":                1,
+		"PATCH /api/v1/application/a1":     1,
+		"POST /api/v1/application/a1/fiat": 2,
+		"PUT /api/v1/application/a1":       1,
+		"GET /api/v1/application/a1":       2,
+	} {
+		if calls[route] != want {
+			t.Errorf("%s calls = %d; want %d", route, calls[route], want)
+		}
+	}
+
+	want := []ApplicationStatus{StatusDraft, StatusSubmitted, StatusApproved}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v; want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transitions = %v; want %v", transitions, want)
+		}
+	}
+
+	var stored OnboardingState
+	if found, err := store.Load(onboarding.Key(), &stored); !found || err != nil || stored.Status != StatusApproved {
+		t.Errorf("stored = %+v, %v, %v; want approved", stored, found, err)
+	}
+}
+
+func TestOnboardingAfterCrash(t *testing.T) {
+	calls := make(map[string]int)
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		route := r.Method + " " + r.URL.Path
+		calls[route]++
+
+		response := map[string]interface{}{}
+		switch route {
+		case "GET /api/v1/application/identifier/individual/u1":
+			response["id"] = "a1"
+		case "GET /api/v1/application/a1/fiats":
+			response["fiats"] = []interface{}{
+				map[string]interface{}{"id": "fiat1", "currency": "USD", "bankName": "Bank"},
+			}
+		case "POST /api/v1/application/a1/fiat":
+			response["id"] = "fiat2"
+		}
+
+		_, err := writeSuccessResponse(w, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	onboarding := &Onboarding{
+		KYC:        NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret),
+		Store:      NewMemoryStore(),
+		Type:       ApplicationTypeIndividual,
+		Identifier: "u1",
+		Fiats:      []map[string]interface{}{{"currency": "USD"}, {"currency": "EUR"}},
+	}
+	state, err := onboarding.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.ApplicationID != "a1" || len(state.FiatIDs) != 2 || state.FiatIDs[0] != "fiat1" || state.FiatIDs[1] != "fiat2" {
+		t.Errorf("state = %+v; want a1 with fiat1 reused and fiat2 created", state)
+	}
+	if calls["POST /api/v1/application"] != 0 || calls["POST /api/v1/application/a1/fiat"] != 1 {
+		t.Errorf("calls = %v; want the existing application and fiat reused", calls)
+	}
+}
+
+func TestOnboardingUploadAfterCrash(t *testing.T) {
+	calls := make(map[string]int)
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		route := r.Method + " " + r.URL.Path
+		calls[route]++
+
+		response := map[string]interface{}{}
+		switch route {
+		case "GET /api/v1/application/a1":
+			response["files"] = []interface{}{
+				map[string]interface{}{"id": "f1", "name": "passport.jpg"},
+				map[string]interface{}{"id": "f9", "name": "bill.pdf"},
+			}
+		case "POST /api/v1/file":
+			response["id"] = "f2"
+		}
+
+		_, err := writeSuccessResponse(w, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	open := func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader([]byte("document"))), nil
+	}
+	store := NewMemoryStore()
+	onboarding := &Onboarding{
+		KYC:        NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret),
+		Store:      store,
+		Type:       ApplicationTypeIndividual,
+		Identifier: "u1",
+		Documents: []OnboardingDocument{
+			{Name: "passport.jpg", Type: "passport", Open: open},
+			{Name: "selfie.jpg", Type: "selfie", Open: open},
+		},
+		Fiats: []map[string]interface{}{{"currency": "USD"}},
+	}
+	store.Save(onboarding.Key(), &OnboardingState{
+		ApplicationID: "a1",
+		Files:         map[string]string{},
+		Status:        StatusDraft,
+		Uploading:     "passport.jpg",
+	})
+
+	state, err := onboarding.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "fiat id is missing") {
+		t.Fatalf("err = %v; want missing fiat id", err)
+	}
+	if state.Files["passport.jpg"] != "f1" || state.Files["selfie.jpg"] != "f2" || state.Uploading != "" {
+		t.Errorf("state = %+v; want f1 reused and f2 uploaded", state)
+	}
+	if calls["POST /api/v1/file"] != 1 {
+		t.Errorf("uploads = %d; want 1", calls["POST /api/v1/file"])
+	}
+	if len(state.FiatIDs) != 0 {
+		t.Errorf("fiats = %v; want none saved without an id", state.FiatIDs)
+	}
+}
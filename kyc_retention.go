@@ -0,0 +1,302 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const retentionKeyPrefix = "retention/"
+
+// RetentionRecord is the end of the business relationship of an
+// application, from which its retention period runs.
+type RetentionRecord struct {
+	ApplicationID string    `json:"applicationID"`
+	Type          string    `json:"type"`
+	EndedAt       time.Time `json:"endedAt"`
+}
+
+// PurgedFile is a cached file removed by a purge.
+type PurgedFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// PurgeCertificate records the personal data removed for an application.
+// Signature is the hex encoded HMAC-SHA256 of the JSON encoding of the other
+// fields, keyed with the SigningKey of Retention.
+// Error is set on the certificate of a purge which failed part way, it lists
+// the data removed before the failure.
+type PurgeCertificate struct {
+	ApplicationID   string       `json:"applicationID"`
+	Type            string       `json:"type"`
+	EndedAt         time.Time    `json:"endedAt"`
+	RetentionMonths int          `json:"retentionMonths"`
+	PurgedAt        time.Time    `json:"purgedAt"`
+	FiatIDs         []string     `json:"fiatIDs"`
+	Files           []PurgedFile `json:"files"`
+	Error           string       `json:"error,omitempty"`
+	Signature       string       `json:"signature,omitempty"`
+}
+
+// Retention purges the personal data of applications once the retention
+// period of their type has passed since the relationship ended. Ended
+// relationships are recorded with Track and persisted in Store under
+// retention/ keys.
+type Retention struct {
+	KYC   *KYC
+	Store Store
+	// Policies are the months personal data is retained for every
+	// application type, types without a policy are never purged.
+	Policies map[string]int
+	// CacheDir holds the locally cached files of every application in a
+	// sub directory named by its id, empty if files are not cached.
+	CacheDir string
+	// SigningKey signs the purge certificates, it is required and should not
+	// be the api secret.
+	SigningKey string
+
+	// Now returns the current time, nil uses time.Now.
+	Now func() time.Time
+}
+
+// Track records the end of the relationship of an application.
+func (r *Retention) Track(applicationID, applicationType string, endedAt time.Time) error {
+	if err := checkRetentionID(applicationID); err != nil {
+		return err
+	}
+	return r.Store.Save(retentionKeyPrefix+applicationID, &RetentionRecord{
+		ApplicationID: applicationID,
+		Type:          applicationType,
+		EndedAt:       endedAt,
+	})
+}
+
+// Expired returns the applications past their retention period, oldest first.
+func (r *Retention) Expired() ([]*RetentionRecord, error) {
+	keys, err := r.Store.Keys(retentionKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.now()
+	var expired []*RetentionRecord
+	for _, key := range keys {
+		record := &RetentionRecord{}
+		found, err := r.Store.Load(key, record)
+		if err != nil {
+			return nil, err
+		}
+		months, ok := r.Policies[record.Type]
+		if !found || !ok {
+			continue
+		}
+		if !record.EndedAt.AddDate(0, months, 0).After(now) {
+			expired = append(expired, record)
+		}
+	}
+	sort.SliceStable(expired, func(i, j int) bool {
+		return expired[i].EndedAt.Before(expired[j].EndedAt)
+	})
+	return expired, nil
+}
+
+// Purge deletes the fiat accounts of an expired application with FiatDelete
+// and its cached files, and returns the signed certificate of the removal.
+// Applications not yet expired are refused. If a deletion fails, the signed
+// certificate of the data already removed is returned with the error, and
+// the application stays tracked so that purging it again removes the rest.
+func (r *Retention) Purge(ctx context.Context, applicationID string) (*PurgeCertificate, error) {
+	if r.SigningKey == "" {
+		return nil, errors.New("signing key is empty")
+	}
+	if err := checkRetentionID(applicationID); err != nil {
+		return nil, err
+	}
+	record := &RetentionRecord{}
+	found, err := r.Store.Load(retentionKeyPrefix+applicationID, record)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("application %s is not tracked", applicationID)
+	}
+	months, ok := r.Policies[record.Type]
+	if !ok {
+		return nil, fmt.Errorf("no retention policy for application type %s", record.Type)
+	}
+	if record.EndedAt.AddDate(0, months, 0).After(r.now()) {
+		return nil, fmt.Errorf("application %s is still retained", applicationID)
+	}
+
+	cert := &PurgeCertificate{
+		ApplicationID:   applicationID,
+		Type:            record.Type,
+		EndedAt:         record.EndedAt,
+		RetentionMonths: months,
+		FiatIDs:         []string{},
+		Files:           []PurgedFile{},
+	}
+
+	kyc := r.KYC.WithContext(ctx)
+	ret, err := checkResult(kyc.FiatsGet(applicationID))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range fiatList(ret.Data) {
+		fiat, _ := item.(map[string]interface{})
+		fiatID := stringField(fiat, "id")
+		if fiatID == "" {
+			continue
+		}
+		if _, err = checkResult(kyc.FiatDelete(fiatID)); err != nil {
+			return r.sign(cert, fmt.Errorf("delete fiat %s failed: %v", fiatID, err))
+		}
+		cert.FiatIDs = append(cert.FiatIDs, fiatID)
+	}
+
+	if cert.Files, err = r.purgeCache(applicationID); err != nil {
+		return r.sign(cert, err)
+	}
+	if err = r.Store.Delete(retentionKeyPrefix + applicationID); err != nil {
+		return r.sign(cert, err)
+	}
+	return r.sign(cert, nil)
+}
+
+// sign dates and signs the certificate of a purge ended by failure, which is
+// returned along the certificate.
+func (r *Retention) sign(cert *PurgeCertificate, failure error) (*PurgeCertificate, error) {
+	if failure != nil {
+		cert.Error = failure.Error()
+	}
+	cert.PurgedAt = r.now().UTC()
+	cert.Signature = ""
+	signature, err := r.signature(cert)
+	if err != nil {
+		return nil, err
+	}
+	cert.Signature = signature
+	return cert, failure
+}
+
+// PurgeExpired purges every expired application, it stops at the first
+// failure and returns the certificates of the applications purged before,
+// followed by the partial certificate of the failed one if any.
+func (r *Retention) PurgeExpired(ctx context.Context) ([]*PurgeCertificate, error) {
+	expired, err := r.Expired()
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*PurgeCertificate
+	for _, record := range expired {
+		cert, err := r.Purge(ctx, record.ApplicationID)
+		if cert != nil {
+			certs = append(certs, cert)
+		}
+		if err != nil {
+			return certs, err
+		}
+	}
+	return certs, nil
+}
+
+// VerifyPurgeCertificate checks the signature of a certificate.
+func (r *Retention) VerifyPurgeCertificate(cert *PurgeCertificate) error {
+	if r.SigningKey == "" {
+		return errors.New("signing key is empty")
+	}
+	unsigned := *cert
+	unsigned.Signature = ""
+	expected, err := r.signature(&unsigned)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(expected), []byte(cert.Signature)) {
+		return errors.New("invalid purge certificate signature")
+	}
+	return nil
+}
+
+// signature is the HMAC-SHA256 of the JSON encoding of the certificate,
+// which escapes the values unlike the message of api signatures.
+func (r *Retention) signature(cert *PurgeCertificate) (string, error) {
+	buf, err := json.Marshal(cert)
+	if err != nil {
+		return "", err
+	}
+	h := hmac.New(sha256.New, []byte(r.SigningKey))
+	h.Write(buf)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// purgeCache removes the cached files of the application, and returns those
+// removed before a failure with the error.
+func (r *Retention) purgeCache(applicationID string) ([]PurgedFile, error) {
+	files := []PurgedFile{}
+	if r.CacheDir == "" {
+		return files, nil
+	}
+	dir := filepath.Join(r.CacheDir, applicationID)
+	rel, err := filepath.Rel(r.CacheDir, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return files, fmt.Errorf("cache of application %s is outside the cache dir", applicationID)
+	}
+	var found []PurgedFile
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(r.CacheDir, path)
+			found = append(found, PurgedFile{Path: filepath.ToSlash(rel), Size: info.Size()})
+		}
+		return nil
+	})
+	if err != nil {
+		return files, err
+	}
+	for _, file := range found {
+		if err = os.Remove(filepath.Join(r.CacheDir, filepath.FromSlash(file.Path))); err != nil && !os.IsNotExist(err) {
+			return files, err
+		}
+		files = append(files, file)
+	}
+	if err = os.RemoveAll(dir); err != nil {
+		return files, err
+	}
+	return files, nil
+}
+
+// checkRetentionID refuses ids which are not a single path element, as they
+// name the cache dir of the application.
+func checkRetentionID(applicationID string) error {
+	if len(applicationID) == 0 {
+		return errors.New("applicationID is empty")
+	}
+	base := filepath.Base(applicationID)
+	if base == "." || base == ".." || base != applicationID || strings.ContainsAny(applicationID, `/\`) {
+		return fmt.Errorf("invalid applicationID %q", applicationID)
+	}
+	return nil
+}
+
+func (r *Retention) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
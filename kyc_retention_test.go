@@ -0,0 +1,182 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRetentionPurge(t *testing.T) {
+	var deleted []string
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		data := map[string]interface{}{}
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/application/a1/fiats":
+			data["fiats"] = []interface{}{
+				map[string]interface{}{"id": "f1"},
+				map[string]interface{}{"id": "f2"},
+			}
+		case r.Method == "DELETE":
+			deleted = append(deleted, r.URL.Path)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		_, err := writeSuccessResponse(w, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	cache, err := ioutil.TempDir("", "retention")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	os.MkdirAll(filepath.Join(cache, "a1", "docs"), 0700)
+	ioutil.WriteFile(filepath.Join(cache, "a1", "docs", "passport.jpg"), []byte("passport"), 0600)
+	os.MkdirAll(filepath.Join(cache, "a2"), 0700)
+	ioutil.WriteFile(filepath.Join(cache, "a2", "bill.pdf"), []byte("bill"), 0600)
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	retention := &Retention{
+		KYC:      NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret),
+		Store:    NewMemoryStore(),
+		Policies: map[string]int{ApplicationTypeIndividual: 60, ApplicationTypeCorporate: 120},
+		CacheDir: cache,
+		Now:      func() time.Time { return now },
+	}
+	retention.Track("a1", ApplicationTypeIndividual, now.AddDate(-6, 0, 0))
+	retention.Track("a2", ApplicationTypeCorporate, now.AddDate(-6, 0, 0))
+	retention.Track("a3", "unknown", now.AddDate(-20, 0, 0))
+
+	expired, err := retention.Expired()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].ApplicationID != "a1" {
+		t.Fatalf("expired = %+v; want a1", expired)
+	}
+	if _, err = retention.Purge(context.Background(), "a1"); err == nil {
+		t.Error("want error purging without a signing key")
+	}
+	retention.SigningKey = "purge-key"
+	if _, err = retention.Purge(context.Background(), "a2"); err == nil {
+		t.Error("want error purging a retained application")
+	}
+
+	certs, err := retention.PurgeExpired(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 1 {
+		t.Fatalf("certificates = %+v; want 1", certs)
+	}
+	cert := certs[0]
+	if len(cert.FiatIDs) != 2 || len(deleted) != 2 || deleted[1] != "/api/v1/fiat/f2" {
+		t.Errorf("fiats = %v, deleted = %v; want f1 and f2", cert.FiatIDs, deleted)
+	}
+	if len(cert.Files) != 1 || cert.Files[0].Path != "a1/docs/passport.jpg" || cert.Files[0].Size != 8 {
+		t.Errorf("files = %+v; want a1/docs/passport.jpg", cert.Files)
+	}
+	if _, err = os.Stat(filepath.Join(cache, "a1")); !os.IsNotExist(err) {
+		t.Errorf("stat a1 = %v; want removed", err)
+	}
+	if _, err = os.Stat(filepath.Join(cache, "a2", "bill.pdf")); err != nil {
+		t.Errorf("stat a2 = %v; want kept", err)
+	}
+	if expired, _ = retention.Expired(); len(expired) != 0 {
+		t.Errorf("expired = %+v; want none after purge", expired)
+	}
+
+	buf, _ := json.Marshal(cert)
+	var loaded PurgeCertificate
+	json.Unmarshal(buf, &loaded)
+	if err = retention.VerifyPurgeCertificate(&loaded); err != nil {
+		t.Error(err)
+	}
+	loaded.FiatIDs = loaded.FiatIDs[:1]
+	if err = retention.VerifyPurgeCertificate(&loaded); err == nil {
+		t.Error("want invalid signature of a tampered certificate")
+	}
+	json.Unmarshal(buf, &loaded)
+	verifier := &Retention{SigningKey: TestAppSecret}
+	if err = verifier.VerifyPurgeCertificate(&loaded); err == nil {
+		t.Error("want invalid signature with another key")
+	}
+}
+
+func TestRetentionPurgeFailure(t *testing.T) {
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		data := map[string]interface{}{}
+		switch {
+		case r.Method == "GET":
+			data["fiats"] = []interface{}{
+				map[string]interface{}{"id": "f1"},
+				map[string]interface{}{"id": "f2"},
+			}
+		case r.URL.Path == "/api/v1/fiat/f2":
+			writeErrorResponse(w, 500, "bank unavailable")
+			return
+		}
+
+		_, err := writeSuccessResponse(w, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	parent, err := ioutil.TempDir("", "retention")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(parent)
+	cache := filepath.Join(parent, "cache")
+	os.MkdirAll(cache, 0700)
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	retention := &Retention{
+		KYC:        NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret),
+		Store:      NewMemoryStore(),
+		Policies:   map[string]int{ApplicationTypeIndividual: 60},
+		CacheDir:   cache,
+		SigningKey: "purge-key",
+		Now:        func() time.Time { return now },
+	}
+	for _, id := range []string{"..", "a/..", "../cache", "."} {
+		if err = retention.Track(id, ApplicationTypeIndividual, now.AddDate(-6, 0, 0)); err == nil {
+			t.Errorf("track %q want error", id)
+		}
+		if _, err = retention.Purge(context.Background(), id); err == nil {
+			t.Errorf("purge %q want error", id)
+		}
+	}
+	if _, err = os.Stat(cache); err != nil {
+		t.Fatalf("stat cache = %v; want kept", err)
+	}
+
+	retention.Track("a1", ApplicationTypeIndividual, now.AddDate(-6, 0, 0))
+	cert, err := retention.Purge(context.Background(), "a1")
+	if err == nil {
+		t.Fatal("want fiat deletion error")
+	}
+	if cert == nil || len(cert.FiatIDs) != 1 || cert.FiatIDs[0] != "f1" || cert.Error == "" {
+		t.Fatalf("certificate = %+v; want partial certificate of f1", cert)
+	}
+	if err = retention.VerifyPurgeCertificate(cert); err != nil {
+		t.Error(err)
+	}
+	if expired, _ := retention.Expired(); len(expired) != 1 {
+		t.Errorf("expired = %+v; want a1 still tracked", expired)
+	}
+}
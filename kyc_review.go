@@ -0,0 +1,282 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RiskTier is the risk level of a customer.
+type RiskTier string
+
+// Risk tiers.
+const (
+	RiskLow    RiskTier = "low"
+	RiskMedium RiskTier = "medium"
+	RiskHigh   RiskTier = "high"
+)
+
+// DefaultReviewIntervals are the months between periodic reviews of every tier.
+var DefaultReviewIntervals = map[RiskTier]int{
+	RiskLow:    36,
+	RiskMedium: 24,
+	RiskHigh:   12,
+}
+
+// DefaultReminderLead is how long before a review is due its reminder is sent.
+const DefaultReminderLead = 30 * 24 * time.Hour
+
+const reviewKeyPrefix = "review/"
+
+// ReviewRecord is the periodic review schedule of an application.
+type ReviewRecord struct {
+	ApplicationID string    `json:"applicationID"`
+	Tier          RiskTier  `json:"tier"`
+	ApprovedAt    time.Time `json:"approvedAt"`
+	LastReviewAt  time.Time `json:"lastReviewAt"`
+	DueAt         time.Time `json:"dueAt"`
+	RemindedAt    time.Time `json:"remindedAt,omitempty"`
+	// EscalatedAt is set once the application is moved back to review.
+	EscalatedAt time.Time `json:"escalatedAt,omitempty"`
+}
+
+// ReviewScheduler tracks when approved applications are due for a periodic
+// review. Check reminds of reviews due within ReminderLead and, once due,
+// calls OnDue and moves the application back to review with
+// ApplicationSettingsUpdate if AutoReview is set. Schedules are persisted in
+// Store under review/ keys.
+type ReviewScheduler struct {
+	KYC   *KYC
+	Store Store
+	// Intervals are the months between reviews of every tier, nil uses
+	// DefaultReviewIntervals.
+	Intervals    map[RiskTier]int
+	ReminderLead time.Duration
+	// AutoReview moves due applications back to review with ReviewSettings.
+	AutoReview     bool
+	ReviewSettings map[string]interface{}
+
+	OnReminder func(record *ReviewRecord)
+	OnDue      func(record *ReviewRecord)
+	// OnError is called with the failures of a record, which do not stop the
+	// other records, and with a nil record for the failures of Run listing
+	// the schedules.
+	OnError func(record *ReviewRecord, err error)
+
+	// Now returns the current time, nil uses time.Now.
+	Now func() time.Time
+}
+
+// Track schedules the reviews of an application approved at approvedAt,
+// replacing its previous schedule.
+func (s *ReviewScheduler) Track(applicationID string, tier RiskTier, approvedAt time.Time) (*ReviewRecord, error) {
+	if len(applicationID) == 0 {
+		return nil, errors.New("applicationID is empty")
+	}
+	record := &ReviewRecord{ApplicationID: applicationID, ApprovedAt: approvedAt}
+	if err := s.schedule(record, tier, approvedAt); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Reviewed records a completed review, with the tier assessed by it, and
+// schedules the next review.
+func (s *ReviewScheduler) Reviewed(applicationID string, tier RiskTier, reviewedAt time.Time) (*ReviewRecord, error) {
+	record, err := s.Get(applicationID)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.schedule(record, tier, reviewedAt); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Untrack stops scheduling reviews of an application.
+func (s *ReviewScheduler) Untrack(applicationID string) error {
+	return s.Store.Delete(reviewKeyPrefix + applicationID)
+}
+
+// Get returns the schedule of an application.
+func (s *ReviewScheduler) Get(applicationID string) (*ReviewRecord, error) {
+	record := &ReviewRecord{}
+	found, err := s.Store.Load(reviewKeyPrefix+applicationID, record)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("application %s is not tracked", applicationID)
+	}
+	return record, nil
+}
+
+// Due returns the schedules due within d from now, earliest first.
+func (s *ReviewScheduler) Due(d time.Duration) ([]*ReviewRecord, error) {
+	records, err := s.records()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := s.now().Add(d)
+	var due []*ReviewRecord
+	for _, record := range records {
+		if !record.DueAt.After(limit) {
+			due = append(due, record)
+		}
+	}
+	return due, nil
+}
+
+// Check sends the reminders and processes the reviews due. A record is
+// reminded and escalated once per review. A record which fails is reported
+// to OnError and retried by the next Check, and Check returns an error
+// counting the failures once every record is processed.
+func (s *ReviewScheduler) Check(ctx context.Context) error {
+	failed, err := s.check(ctx)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d reviews failed", failed)
+	}
+	return nil
+}
+
+// check returns the number of records which failed, or the error stopping
+// the check.
+func (s *ReviewScheduler) check(ctx context.Context) (int, error) {
+	if s.AutoReview && s.KYC == nil {
+		return 0, errors.New("kyc is nil")
+	}
+	lead := s.ReminderLead
+	if lead <= 0 {
+		lead = DefaultReminderLead
+	}
+	records, err := s.Due(lead)
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.now()
+	failed := 0
+	for _, record := range records {
+		if err = ctx.Err(); err != nil {
+			return failed, err
+		}
+		if err = s.process(ctx, record, now); err != nil {
+			failed++
+			if s.OnError != nil {
+				s.OnError(record, err)
+			}
+		}
+	}
+	return failed, nil
+}
+
+func (s *ReviewScheduler) process(ctx context.Context, record *ReviewRecord, now time.Time) error {
+	if record.RemindedAt.IsZero() {
+		record.RemindedAt = now
+		if err := s.save(record); err != nil {
+			return err
+		}
+		if s.OnReminder != nil {
+			s.OnReminder(record)
+		}
+	}
+
+	if record.DueAt.After(now) || !record.EscalatedAt.IsZero() {
+		return nil
+	}
+	if s.AutoReview {
+		settings := s.ReviewSettings
+		if settings == nil {
+			settings = map[string]interface{}{"status": "review"}
+		}
+		_, err := checkResult(s.KYC.WithContext(ctx).ApplicationSettingsUpdate(record.ApplicationID, settings))
+		if err != nil {
+			return fmt.Errorf("move application %s to review failed: %v", record.ApplicationID, err)
+		}
+	}
+	record.EscalatedAt = now
+	if err := s.save(record); err != nil {
+		return err
+	}
+	if s.OnDue != nil {
+		s.OnDue(record)
+	}
+	return nil
+}
+
+// Run checks the reviews every interval until ctx is done. Failures are
+// reported to OnError and checked again at the next interval.
+func (s *ReviewScheduler) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.check(ctx); err != nil && ctx.Err() == nil && s.OnError != nil {
+			s.OnError(nil, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ReviewScheduler) schedule(record *ReviewRecord, tier RiskTier, from time.Time) error {
+	intervals := s.Intervals
+	if intervals == nil {
+		intervals = DefaultReviewIntervals
+	}
+	months, ok := intervals[tier]
+	if !ok || months <= 0 {
+		return fmt.Errorf("no review interval for risk tier %s", tier)
+	}
+
+	record.Tier = tier
+	record.LastReviewAt = from
+	record.DueAt = from.AddDate(0, months, 0)
+	record.RemindedAt = time.Time{}
+	record.EscalatedAt = time.Time{}
+	return s.save(record)
+}
+
+func (s *ReviewScheduler) save(record *ReviewRecord) error {
+	return s.Store.Save(reviewKeyPrefix+record.ApplicationID, record)
+}
+
+func (s *ReviewScheduler) records() ([]*ReviewRecord, error) {
+	keys, err := s.Store.Keys(reviewKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*ReviewRecord, 0, len(keys))
+	for _, key := range keys {
+		record := &ReviewRecord{}
+		found, err := s.Store.Load(key, record)
+		if err != nil {
+			return nil, fmt.Errorf("load %s failed: %v", strings.TrimPrefix(key, reviewKeyPrefix), err)
+		}
+		if found {
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].DueAt.Before(records[j].DueAt)
+	})
+	return records, nil
+}
+
+func (s *ReviewScheduler) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
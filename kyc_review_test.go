@@ -0,0 +1,125 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReviewScheduler(t *testing.T) {
+	var updated []string
+	var body map[string]interface{}
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			t.Errorf("method = %s; want PUT", r.Method)
+		}
+		updated = append(updated, r.URL.Path)
+		json.NewDecoder(r.Body).Decode(&body)
+
+		_, err := writeSuccessResponse(w, map[string]interface{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var reminded, due []string
+	scheduler := &ReviewScheduler{
+		KYC:            NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret),
+		Store:          NewMemoryStore(),
+		AutoReview:     true,
+		ReviewSettings: map[string]interface{}{"reviewRequired": true},
+		OnReminder:     func(record *ReviewRecord) { reminded = append(reminded, record.ApplicationID) },
+		OnDue:          func(record *ReviewRecord) { due = append(due, record.ApplicationID) },
+		Now:            func() time.Time { return now },
+	}
+
+	high, err := scheduler.Track("high", RiskHigh, now.AddDate(-1, 0, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := now.AddDate(0, 0, -1); !high.DueAt.Equal(want) {
+		t.Errorf("due = %v; want %v", high.DueAt, want)
+	}
+	scheduler.Track("medium", RiskMedium, now.AddDate(-2, 0, 10))
+	scheduler.Track("low", RiskLow, now.AddDate(-1, 0, 0))
+	if _, err = scheduler.Track("x", RiskTier("unknown"), now); err == nil {
+		t.Error("want error for unknown tier")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err = scheduler.Check(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(reminded) != 2 || reminded[0] != "high" || reminded[1] != "medium" {
+		t.Errorf("reminded = %v; want high and medium once", reminded)
+	}
+	if len(due) != 1 || due[0] != "high" || len(updated) != 1 || updated[0] != "/api/v1/application/high/settings" {
+		t.Errorf("due = %v, updated = %v; want high moved to review once", due, updated)
+	}
+	if body["reviewRequired"] != true {
+		t.Errorf("body = %v; want review settings", body)
+	}
+
+	record, err := scheduler.Reviewed("high", RiskMedium, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !record.DueAt.Equal(now.AddDate(2, 0, 0)) || !record.EscalatedAt.IsZero() {
+		t.Errorf("record = %+v; want next review in 2 years", record)
+	}
+	pending, err := scheduler.Due(0)
+	if err != nil || len(pending) != 0 {
+		t.Errorf("due = %v, %v; want none", pending, err)
+	}
+}
+
+func TestReviewSchedulerError(t *testing.T) {
+	failing := "/api/v1/application/a/settings"
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == failing {
+			writeErrorResponse(w, 500, "internal error")
+			return
+		}
+		_, err := writeSuccessResponse(w, map[string]interface{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var due, failed []string
+	scheduler := &ReviewScheduler{
+		KYC:        NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret),
+		Store:      NewMemoryStore(),
+		AutoReview: true,
+		OnDue:      func(record *ReviewRecord) { due = append(due, record.ApplicationID) },
+		OnError:    func(record *ReviewRecord, err error) { failed = append(failed, record.ApplicationID) },
+		Now:        func() time.Time { return now },
+	}
+	scheduler.Track("a", RiskHigh, now.AddDate(-1, 0, -2))
+	scheduler.Track("b", RiskHigh, now.AddDate(-1, 0, -1))
+
+	if err := scheduler.Check(context.Background()); err == nil {
+		t.Error("want error for the failed review")
+	}
+	if len(failed) != 1 || failed[0] != "a" || len(due) != 1 || due[0] != "b" {
+		t.Errorf("failed = %v, due = %v; want a failed and b moved to review", failed, due)
+	}
+
+	failing = ""
+	if err := scheduler.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 2 || due[1] != "a" {
+		t.Errorf("due = %v; want a retried", due)
+	}
+}
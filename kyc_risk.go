@@ -0,0 +1,315 @@
+package jadepoolsaas
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRiskField is the application field the assessment is written to.
+const DefaultRiskField = "riskAssessment"
+
+// RiskRule adds its weight to the score of an application whose field
+// satisfies every condition set. A field path through a list, such as
+// ubos.nationality, is satisfied if any element is.
+type RiskRule struct {
+	Name   string  `yaml:"name"`
+	Field  string  `yaml:"field"`
+	Weight float64 `yaml:"weight"`
+	// Reason explains the rule in the assessment, the name is used if empty.
+	Reason string `yaml:"reason"`
+
+	// Equals and In compare values case insensitively.
+	Equals  interface{}   `yaml:"equals"`
+	In      []interface{} `yaml:"in"`
+	NotIn   []interface{} `yaml:"notIn"`
+	Matches string        `yaml:"matches"`
+	Above   *float64      `yaml:"above"`
+	Below   *float64      `yaml:"below"`
+	// Missing is satisfied by an empty or absent field if true, and by a
+	// provided one if false.
+	Missing *bool `yaml:"missing"`
+	// CountBelow is satisfied by a list with fewer elements.
+	CountBelow *int `yaml:"countBelow"`
+
+	matches *regexp.Regexp
+}
+
+// RiskTierLimit is the highest score of a tier, the last tier has no limit.
+type RiskTierLimit struct {
+	Tier RiskTier `yaml:"tier"`
+	Max  *float64 `yaml:"max"`
+}
+
+// RiskModel is a set of rules scoring the risk of applications, configured
+// in YAML:
+//
+//	field: riskAssessment
+//	tiers:
+//	  - {tier: low, max: 30}
+//	  - {tier: medium, max: 60}
+//	  - {tier: high}
+//	rules:
+//	  - name: high risk country
+//	    field: address.country
+//	    in: [IR, KP, SY]
+//	    weight: 40
+//	  - name: pep match
+//	    field: screening.hit
+//	    equals: true
+//	    weight: 50
+type RiskModel struct {
+	// Field is the application field written by ApplicationRiskUpdate.
+	Field string          `yaml:"field"`
+	Tiers []RiskTierLimit `yaml:"tiers"`
+	Rules []*RiskRule     `yaml:"rules"`
+}
+
+// RiskExplanation is a rule which applied to the application.
+type RiskExplanation struct {
+	Rule   string      `json:"rule"`
+	Reason string      `json:"reason"`
+	Weight float64     `json:"weight"`
+	Field  string      `json:"field"`
+	Value  interface{} `json:"value,omitempty"`
+}
+
+// RiskAssessment is the score of an application with the rules applied.
+type RiskAssessment struct {
+	Score        float64           `json:"score"`
+	Tier         RiskTier          `json:"tier"`
+	Explanations []RiskExplanation `json:"explanations"`
+	AssessedAt   time.Time         `json:"assessedAt"`
+}
+
+var defaultRiskTiers = []RiskTierLimit{
+	{Tier: RiskLow, Max: floatPtr(30)},
+	{Tier: RiskMedium, Max: floatPtr(60)},
+	{Tier: RiskHigh},
+}
+
+// ParseRiskModel parses and checks a YAML risk model. Without tiers, scores
+// up to 30 are low, up to 60 medium, and above high.
+func ParseRiskModel(data []byte) (*RiskModel, error) {
+	model := &RiskModel{}
+	if err := yaml.Unmarshal(data, model); err != nil {
+		return nil, fmt.Errorf("parse risk model failed: %v", err)
+	}
+	if model.Field == "" {
+		model.Field = DefaultRiskField
+	}
+	if len(model.Tiers) == 0 {
+		model.Tiers = defaultRiskTiers
+	}
+
+	for i, tier := range model.Tiers {
+		if tier.Tier == "" {
+			return nil, fmt.Errorf("tier %d has no name", i)
+		}
+		if tier.Max == nil && i != len(model.Tiers)-1 {
+			return nil, fmt.Errorf("tier %s must have a max score", tier.Tier)
+		}
+	}
+	if !sort.SliceIsSorted(model.Tiers, func(i, j int) bool {
+		return model.Tiers[j].Max == nil || model.Tiers[i].Max != nil && *model.Tiers[i].Max < *model.Tiers[j].Max
+	}) {
+		return nil, errors.New("tiers must be ordered by max score")
+	}
+
+	for i, rule := range model.Rules {
+		if rule.Name == "" || rule.Field == "" {
+			return nil, fmt.Errorf("rule %d must have a name and a field", i)
+		}
+		if rule.Equals == nil && rule.In == nil && rule.NotIn == nil && rule.Matches == "" &&
+			rule.Above == nil && rule.Below == nil && rule.Missing == nil && rule.CountBelow == nil {
+			return nil, fmt.Errorf("rule %s has no condition", rule.Name)
+		}
+		if rule.Matches != "" {
+			re, err := regexp.Compile(rule.Matches)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s has an invalid pattern: %v", rule.Name, err)
+			}
+			rule.matches = re
+		}
+	}
+	return model, nil
+}
+
+// LoadRiskModel reads and parses a YAML risk model file.
+func LoadRiskModel(path string) (*RiskModel, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRiskModel(data)
+}
+
+// Assess scores the application, the data of an ApplicationGet result.
+func (m *RiskModel) Assess(application map[string]interface{}) *RiskAssessment {
+	assessment := &RiskAssessment{
+		Explanations: []RiskExplanation{},
+		AssessedAt:   time.Now().UTC(),
+	}
+	for _, rule := range m.Rules {
+		value, ok := rule.apply(application)
+		if !ok {
+			continue
+		}
+		reason := rule.Reason
+		if reason == "" {
+			reason = rule.Name
+		}
+		assessment.Score += rule.Weight
+		assessment.Explanations = append(assessment.Explanations, RiskExplanation{
+			Rule:   rule.Name,
+			Reason: reason,
+			Weight: rule.Weight,
+			Field:  rule.Field,
+			Value:  value,
+		})
+	}
+
+	for _, tier := range m.Tiers {
+		assessment.Tier = tier.Tier
+		if tier.Max == nil || assessment.Score <= *tier.Max {
+			break
+		}
+	}
+	return assessment
+}
+
+// apply returns whether the rule applies, with the value satisfying it.
+func (r *RiskRule) apply(application map[string]interface{}) (interface{}, bool) {
+	found := lookupField("", application, strings.Split(r.Field, "."))
+	var present []interface{}
+	for _, f := range found {
+		if !isEmptyValue(f.value) {
+			present = append(present, f.value)
+		}
+	}
+
+	if r.Missing != nil && *r.Missing != (len(present) == 0) {
+		return nil, false
+	}
+	if r.CountBelow != nil {
+		count := 0
+		if len(found) == 1 {
+			if list, ok := found[0].value.([]interface{}); ok {
+				count = len(list)
+			}
+		}
+		if count >= *r.CountBelow {
+			return nil, false
+		}
+		return count, true
+	}
+	if r.Equals == nil && r.In == nil && r.NotIn == nil && r.matches == nil && r.Above == nil && r.Below == nil {
+		return nil, true
+	}
+
+	for _, value := range present {
+		values := []interface{}{value}
+		if list, ok := value.([]interface{}); ok {
+			values = list
+		}
+		for _, v := range values {
+			if r.satisfies(v) {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (r *RiskRule) satisfies(value interface{}) bool {
+	if r.Equals != nil && !riskValuesEqual(r.Equals, value) {
+		return false
+	}
+	if r.In != nil && !riskValuesContain(r.In, value) {
+		return false
+	}
+	if r.NotIn != nil && riskValuesContain(r.NotIn, value) {
+		return false
+	}
+	if r.matches != nil && !r.matches.MatchString(fmt.Sprint(value)) {
+		return false
+	}
+	if r.Above != nil || r.Below != nil {
+		n, ok := riskNumber(value)
+		if !ok || r.Above != nil && n <= *r.Above || r.Below != nil && n >= *r.Below {
+			return false
+		}
+	}
+	return true
+}
+
+func riskValuesContain(list []interface{}, value interface{}) bool {
+	for _, item := range list {
+		if riskValuesEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func riskValuesEqual(a, b interface{}) bool {
+	na, aIsNumber := riskNumber(a)
+	nb, bIsNumber := riskNumber(b)
+	if aIsNumber && bIsNumber {
+		return na == nb
+	}
+	return strings.EqualFold(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func riskNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+// ApplicationRiskAssess get the expanded application and scores it with the model.
+func (k *KYC) ApplicationRiskAssess(applicationID string, model *RiskModel) (*RiskAssessment, error) {
+	if len(applicationID) == 0 {
+		return nil, errors.New("applicationID is empty")
+	}
+
+	ret, err := checkResult(k.ApplicationGet(applicationID, true))
+	if err != nil {
+		return nil, err
+	}
+	return model.Assess(ret.Data), nil
+}
+
+// ApplicationRiskUpdate writes the assessment to the field of the model with
+// ApplicationUpdate2.
+func (k *KYC) ApplicationRiskUpdate(applicationID string, model *RiskModel, assessment *RiskAssessment) (*Result, error) {
+	if len(applicationID) == 0 {
+		return nil, errors.New("applicationID is empty")
+	}
+
+	var fields map[string]interface{}
+	if err := decodeData(assessment, &fields); err != nil {
+		return nil, err
+	}
+	field := model.Field
+	if field == "" {
+		field = DefaultRiskField
+	}
+	return k.ApplicationUpdate2(applicationID, map[string]interface{}{field: fields})
+}
@@ -0,0 +1,132 @@
+package jadepoolsaas
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testRiskModel = `
+field: risk
+rules:
+  - name: high risk country
+    field: address.country
+    in: [ir, kp, sy]
+    weight: 40
+    reason: resident of a high risk country
+  - name: cash intensive occupation
+    field: occupation
+    matches: (?i)casino|exchange
+    weight: 15
+  - name: unknown source of funds
+    field: sourceOfFunds
+    missing: true
+    weight: 10
+  - name: pep match
+    field: screening.hit
+    equals: true
+    weight: 30
+  - name: missing documents
+    field: files
+    countBelow: 2
+    weight: 10
+  - name: large deposits
+    field: expectedDeposits
+    above: 100000
+    weight: 5
+`
+
+func TestRiskModelAssess(t *testing.T) {
+	model, err := ParseRiskModel([]byte(testRiskModel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	low := model.Assess(map[string]interface{}{
+		"address":          map[string]interface{}{"country": "CN"},
+		"occupation":       "engineer",
+		"sourceOfFunds":    "salary",
+		"screening":        map[string]interface{}{"hit": false},
+		"files":            []interface{}{"f1", "f2"},
+		"expectedDeposits": 5000.0,
+	})
+	if low.Score != 0 || low.Tier != RiskLow || len(low.Explanations) != 0 {
+		t.Errorf("assessment = %+v; want low with no explanations", low)
+	}
+
+	high := model.Assess(map[string]interface{}{
+		"address":          map[string]interface{}{"country": "IR"},
+		"occupation":       "Casino manager",
+		"screening":        map[string]interface{}{"hit": true},
+		"files":            []interface{}{"f1"},
+		"expectedDeposits": 200000.0,
+	})
+	if high.Score != 110 || high.Tier != RiskHigh || len(high.Explanations) != 6 {
+		t.Fatalf("assessment = %+v; want high with 6 explanations", high)
+	}
+	if e := high.Explanations[0]; e.Reason != "resident of a high risk country" || e.Value != "IR" {
+		t.Errorf("explanation = %+v; want high risk country IR", e)
+	}
+	if e := high.Explanations[4]; e.Reason != "missing documents" || e.Value != 1 {
+		t.Errorf("explanation = %+v; want missing documents 1", e)
+	}
+
+	for _, bad := range []string{
+		"rules: [{name: r, field: f, weight: 1}]",
+		"rules: [{name: r, field: f, matches: '('}]",
+		"tiers: [{tier: low}, {tier: high, max: 10}]",
+		"tiers: [{tier: high, max: 60}, {tier: low, max: 30}, {tier: top}]",
+	} {
+		if _, err = ParseRiskModel([]byte(bad)); err == nil {
+			t.Errorf("ParseRiskModel(%q) want error", bad)
+		}
+	}
+}
+
+func TestApplicationRiskAssess(t *testing.T) {
+	var body map[string]interface{}
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		data := map[string]interface{}{}
+		switch r.Method {
+		case "GET":
+			if r.URL.Query().Get("expand") != "true" {
+				t.Errorf("query = %s; want expanded application", r.URL.RawQuery)
+			}
+			data["id"] = "a1"
+			data["address"] = map[string]interface{}{"country": "KP"}
+			data["sourceOfFunds"] = "salary"
+			data["files"] = []interface{}{"f1", "f2"}
+		case "PATCH":
+			json.NewDecoder(r.Body).Decode(&body)
+		}
+
+		_, err := writeSuccessResponse(w, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	model, err := ParseRiskModel([]byte(testRiskModel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret)
+	assessment, err := kyc.ApplicationRiskAssess("a1", model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assessment.Score != 40 || assessment.Tier != RiskMedium {
+		t.Errorf("assessment = %+v; want 40 medium", assessment)
+	}
+
+	if _, err = checkResult(kyc.ApplicationRiskUpdate("a1", model, assessment)); err != nil {
+		t.Fatal(err)
+	}
+	risk, _ := body["risk"].(map[string]interface{})
+	if risk["score"] != 40.0 || risk["tier"] != "medium" {
+		t.Errorf("body = %v; want risk field with the assessment", body)
+	}
+}
@@ -0,0 +1,247 @@
+package jadepoolsaas
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FormTypeSchema is the form of an application type configured by the tenant.
+type FormTypeSchema struct {
+	// RequiredFields are dotted field paths, such as address.city. A path
+	// through a list applies to every element, such as directors.lastName.
+	RequiredFields []string `json:"requiredFields,omitempty"`
+	// DocumentTypes are the accepted document types, empty accepts any type.
+	DocumentTypes []string `json:"documentTypes,omitempty"`
+	// RequiredDocumentTypes must each be provided by a document.
+	RequiredDocumentTypes []string `json:"requiredDocumentTypes,omitempty"`
+	// Countries are the accepted ISO 3166-1 alpha-2 codes, empty accepts any country.
+	Countries []string `json:"countries,omitempty"`
+	// BlockedCountries are refused country codes.
+	BlockedCountries []string `json:"blockedCountries,omitempty"`
+}
+
+// FormSchema is the form of every application type, parsed from the general settings.
+type FormSchema struct {
+	Types map[string]*FormTypeSchema `json:"types"`
+}
+
+// countryFields are the fields holding a country code.
+var countryFields = []string{"country", "nationality", "incorporationCountry"}
+
+// ParseFormSchema parses the general settings into a form schema. The
+// FormTypeSchema fields at the top level of the settings apply to every
+// type, and fields under the name of a type, such as individual, override
+// them for that type.
+func ParseFormSchema(settings map[string]interface{}) (*FormSchema, error) {
+	defaults := &FormTypeSchema{}
+	if err := decodeData(settings, defaults); err != nil {
+		return nil, fmt.Errorf("parse form schema failed: %v", err)
+	}
+
+	schema := &FormSchema{Types: make(map[string]*FormTypeSchema)}
+	for _, applicationType := range []string{ApplicationTypeIndividual, ApplicationTypeCorporate} {
+		typeSchema := &FormTypeSchema{}
+		if typeSettings, ok := settings[applicationType].(map[string]interface{}); ok {
+			if err := decodeData(typeSettings, typeSchema); err != nil {
+				return nil, fmt.Errorf("parse form schema of %s failed: %v", applicationType, err)
+			}
+		}
+		for _, field := range []struct{ value, fallback *[]string }{
+			{&typeSchema.RequiredFields, &defaults.RequiredFields},
+			{&typeSchema.DocumentTypes, &defaults.DocumentTypes},
+			{&typeSchema.RequiredDocumentTypes, &defaults.RequiredDocumentTypes},
+			{&typeSchema.Countries, &defaults.Countries},
+			{&typeSchema.BlockedCountries, &defaults.BlockedCountries},
+		} {
+			if *field.value == nil {
+				*field.value = *field.fallback
+			}
+		}
+		schema.Types[applicationType] = typeSchema
+	}
+	return schema, nil
+}
+
+// FormSchema get the general settings and parses them with ParseFormSchema.
+func (k *KYC) FormSchema() (*FormSchema, error) {
+	ret, err := checkResult(k.GeneralSettingsGet())
+	if err != nil {
+		return nil, err
+	}
+	return ParseFormSchema(ret.Data)
+}
+
+// Validate checks the content of an application of the type against the
+// schema, it returns ValidationErrors listing every missing or invalid field.
+// The content is an ApplicationContent or its json fields.
+func (s *FormSchema) Validate(applicationType string, content interface{}) error {
+	typeSchema, ok := s.Types[applicationType]
+	if !ok {
+		return fmt.Errorf("unknown application type %s", applicationType)
+	}
+
+	var fields map[string]interface{}
+	if err := decodeData(content, &fields); err != nil {
+		return err
+	}
+
+	v := newValidator()
+	typeSchema.validate(v, fields)
+	return v.err()
+}
+
+func (s *FormTypeSchema) validate(v *validator, fields map[string]interface{}) {
+	for _, required := range s.RequiredFields {
+		for _, found := range lookupField("", fields, strings.Split(required, ".")) {
+			if isEmptyValue(found.value) {
+				v.add(found.path, "is required")
+			}
+		}
+	}
+
+	walkFields("", fields, func(path, key string, value interface{}) {
+		country, ok := value.(string)
+		if !ok || country == "" || !containsString(countryFields, key) {
+			return
+		}
+		if containsString(s.BlockedCountries, country) ||
+			len(s.Countries) > 0 && !containsString(s.Countries, country) {
+			v.add(path, "country "+country+" is not accepted")
+		}
+	})
+
+	documents, _ := fields["documents"].([]interface{})
+	provided := make(map[string]bool)
+	for i, item := range documents {
+		doc, _ := item.(map[string]interface{})
+		docType, _ := doc["type"].(string)
+		provided[docType] = true
+		if len(s.DocumentTypes) > 0 && !containsString(s.DocumentTypes, docType) {
+			v.add("documents["+strconv.Itoa(i)+"].type", "document type "+docType+" is not accepted")
+		}
+	}
+	for _, docType := range s.RequiredDocumentTypes {
+		if !provided[docType] {
+			v.add("documents", "a document of type "+docType+" is required")
+		}
+	}
+}
+
+type foundField struct {
+	path  string
+	value interface{}
+}
+
+// lookupField returns the values at the path, a path through a list
+// returns the value of every element.
+func lookupField(prefix string, value interface{}, parts []string) []foundField {
+	if list, ok := value.([]interface{}); ok && len(parts) > 0 {
+		var found []foundField
+		for i, item := range list {
+			found = append(found, lookupField(prefix+"["+strconv.Itoa(i)+"]", item, parts)...)
+		}
+		return found
+	}
+	if len(parts) == 0 {
+		return []foundField{{path: prefix, value: value}}
+	}
+
+	path := parts[0]
+	if prefix != "" {
+		path = prefix + "." + parts[0]
+	}
+	object, _ := value.(map[string]interface{})
+	return lookupField(path, object[parts[0]], parts[1:])
+}
+
+// walkFields calls fn with every field of nested objects and lists, in order.
+func walkFields(prefix string, value interface{}, fn func(path, key string, value interface{})) {
+	switch t := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for key := range t {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			fn(path, key, t[key])
+			walkFields(path, t[key], fn)
+		}
+	case []interface{}:
+		for i, item := range t {
+			walkFields(prefix+"["+strconv.Itoa(i)+"]", item, fn)
+		}
+	}
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch t := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(t) == ""
+	case []interface{}:
+		return len(t) == 0
+	case map[string]interface{}:
+		return len(t) == 0
+	}
+	return false
+}
+
+// ApplicationSubmitValidated gets the pending application with
+// ApplicationGet and validates its content with the Validate method of its
+// type and against the form schema, and submits it only if both pass. The
+// returned ValidationErrors lists the fields failing either. A nil schema is
+// get with FormSchema.
+func (k *KYC) ApplicationSubmitValidated(applicationID string, schema *FormSchema) (*Result, error) {
+	if len(applicationID) == 0 {
+		return nil, errors.New("applicationID is empty")
+	}
+	if schema == nil {
+		var err error
+		if schema, err = k.FormSchema(); err != nil {
+			return nil, err
+		}
+	}
+
+	ret, err := checkResult(k.ApplicationGet(applicationID, false))
+	if err != nil {
+		return nil, err
+	}
+	applicationType := stringField(ret.Data, "type")
+	var content ApplicationContent
+	switch applicationType {
+	case ApplicationTypeIndividual:
+		content = &IndividualApplication{}
+	case ApplicationTypeCorporate:
+		content = &CorporateApplication{}
+	default:
+		return nil, fmt.Errorf("unknown application type %s", applicationType)
+	}
+	if err = ret.Decode(content); err != nil {
+		return nil, fmt.Errorf("parse application failed: %v", err)
+	}
+
+	var errs ValidationErrors
+	for _, err := range []error{content.Validate(), schema.Validate(applicationType, ret.Data)} {
+		if err == nil {
+			continue
+		}
+		fieldErrs, ok := err.(ValidationErrors)
+		if !ok {
+			return nil, err
+		}
+		errs = append(errs, fieldErrs...)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return k.ApplicationSubmit(applicationID)
+}
@@ -0,0 +1,105 @@
+package jadepoolsaas
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApplicationSubmitValidated(t *testing.T) {
+	submitted := false
+	var application map[string]interface{}
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		var data map[string]interface{}
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/application/a1":
+			data = application
+		case r.Method == "GET":
+			data = map[string]interface{}{
+				"blockedCountries": []interface{}{"KP"},
+				"individual": map[string]interface{}{
+					"requiredFields":        []interface{}{"phone", "address.postalCode"},
+					"documentTypes":         []interface{}{"passport", "proofOfAddress"},
+					"requiredDocumentTypes": []interface{}{"passport"},
+					"countries":             []interface{}{"GB", "FR"},
+				},
+				"corporate": map[string]interface{}{
+					"requiredFields": []interface{}{"directors.idNumber"},
+				},
+			}
+		case r.Method == "PUT":
+			submitted = true
+		}
+
+		_, err := writeSuccessResponse(w, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret)
+	schema, err := kyc.FormSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if corporate := schema.Types[ApplicationTypeCorporate]; len(corporate.BlockedCountries) != 1 || len(corporate.Countries) != 0 {
+		t.Errorf("corporate = %+v; want default blocked countries only", corporate)
+	}
+
+	setApplication := func(app *IndividualApplication) {
+		if application, err = contentFields(app); err != nil {
+			t.Fatal(err)
+		}
+		application["id"] = "a1"
+		application["type"] = ApplicationTypeIndividual
+	}
+
+	app := testIndividualApplication()
+	app.Nationality = "US"
+	app.Documents = []Document{{Type: "selfie", FileID: "f1"}}
+	setApplication(app)
+	_, err = kyc.ApplicationSubmitValidated("a1", schema)
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("err = %v; want ValidationErrors", err)
+	}
+	fields := make(map[string]bool)
+	for _, fieldErr := range errs {
+		fields[fieldErr.Field] = true
+	}
+	for _, field := range []string{"phone", "address.postalCode", "nationality", "documents[0].type", "documents"} {
+		if !fields[field] {
+			t.Errorf("errors = %v; want error for %s", errs, field)
+		}
+	}
+	if submitted {
+		t.Error("want invalid application not submitted")
+	}
+
+	corporate := map[string]interface{}{
+		"directors": []interface{}{
+			map[string]interface{}{"firstName": "Bob", "idNumber": "X1"},
+			map[string]interface{}{"firstName": "Carol", "nationality": "KP"},
+		},
+	}
+	err = schema.Validate(ApplicationTypeCorporate, corporate)
+	if !errors.As(err, &errs) || len(errs) != 2 || errs[0].Field != "directors[1].idNumber" || errs[1].Field != "directors[1].nationality" {
+		t.Errorf("err = %v; want directors[1] idNumber and nationality", err)
+	}
+
+	app = testIndividualApplication()
+	app.Phone = "+441234567890"
+	app.Address.PostalCode = "SW1A 1AA"
+	app.Documents = []Document{{Type: "passport", FileID: "f1"}}
+	setApplication(app)
+	if _, err = kyc.ApplicationSubmitValidated("a1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !submitted {
+		t.Error("want valid application submitted")
+	}
+}
@@ -0,0 +1,441 @@
+package jadepoolsaas
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Default screening thresholds.
+const (
+	DefaultScreeningThreshold = 0.85
+	DefaultDateOfBirthPenalty = 0.15
+)
+
+// WatchlistEntry is a sanctioned or politically exposed person or entity.
+type WatchlistEntry struct {
+	ID      string   `json:"id" xml:"id,attr"`
+	Name    string   `json:"name" xml:"name"`
+	Aliases []string `json:"aliases,omitempty" xml:"alias"`
+	// DateOfBirth is formatted as YYYY-MM-DD, YYYY-MM or YYYY.
+	DateOfBirth string `json:"dateOfBirth,omitempty" xml:"dateOfBirth"`
+	Nationality string `json:"nationality,omitempty" xml:"nationality"`
+	// List names the list of the entry, such as sanctions or pep.
+	List string `json:"list" xml:"list"`
+
+	names []watchlistName
+}
+
+type watchlistName struct {
+	name   string
+	tokens []string
+}
+
+// Watchlist is a local list of sanctioned and politically exposed persons.
+// Entries are added with Add, LoadCSV or LoadXML.
+type Watchlist struct {
+	entries []*WatchlistEntry
+}
+
+// Len returns the number of entries of the watchlist.
+func (w *Watchlist) Len() int {
+	return len(w.entries)
+}
+
+// Add adds entries to the watchlist.
+func (w *Watchlist) Add(entries ...*WatchlistEntry) {
+	for _, entry := range entries {
+		entry.names = nil
+		for _, name := range append([]string{entry.Name}, entry.Aliases...) {
+			if tokens := nameTokens(name); len(tokens) > 0 {
+				entry.names = append(entry.names, watchlistName{name: name, tokens: tokens})
+			}
+		}
+		w.entries = append(w.entries, entry)
+	}
+}
+
+// LoadCSV reads entries from a CSV file with a header line naming
+// the columns id, name, aliases, dateOfBirth, nationality and list. Aliases
+// are separated by semicolons, and list defaults to the list argument.
+func (w *Watchlist) LoadCSV(r io.Reader, list string) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("read csv header failed: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return errors.New("watchlist csv has no name column")
+	}
+
+	var entries []*WatchlistEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		entry := &WatchlistEntry{
+			ID:          value("id"),
+			Name:        value("name"),
+			DateOfBirth: value("dateOfBirth"),
+			Nationality: value("nationality"),
+			List:        value("list"),
+		}
+		for _, alias := range strings.Split(value("aliases"), ";") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				entry.Aliases = append(entry.Aliases, alias)
+			}
+		}
+		if entry.List == "" {
+			entry.List = list
+		}
+		if entry.Name != "" {
+			entries = append(entries, entry)
+		}
+	}
+	w.Add(entries...)
+	return nil
+}
+
+// LoadXML reads entries from an XML file of entry elements, with an
+// id attribute and name, alias, dateOfBirth, nationality and list children:
+//
+//	<watchlist>
+//	  <entry id="1"><name>John Doe</name><alias>Johnny Doe</alias></entry>
+//	</watchlist>
+func (w *Watchlist) LoadXML(r io.Reader, list string) error {
+	var doc struct {
+		Entries []*WatchlistEntry `xml:"entry"`
+	}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("parse watchlist xml failed: %v", err)
+	}
+
+	var entries []*WatchlistEntry
+	for _, entry := range doc.Entries {
+		entry.Name = strings.TrimSpace(entry.Name)
+		if entry.List == "" {
+			entry.List = list
+		}
+		if entry.Name != "" {
+			entries = append(entries, entry)
+		}
+	}
+	w.Add(entries...)
+	return nil
+}
+
+// ScreeningOptions configures a screening, zero values use the defaults.
+type ScreeningOptions struct {
+	// Threshold is the minimum score of a reported match, from 0 to 1.
+	Threshold float64
+	// DateOfBirthPenalty is subtracted from the score when both dates of
+	// birth are known and differ.
+	DateOfBirthPenalty float64
+}
+
+// ScreeningMatch is a watchlist entry similar to the screened name.
+type ScreeningMatch struct {
+	EntryID string `json:"entryID"`
+	List    string `json:"list"`
+	// Name is the name or alias of the entry matched.
+	Name        string  `json:"name"`
+	Score       float64 `json:"score"`
+	DateOfBirth string  `json:"dateOfBirth,omitempty"`
+	// DateOfBirthMatch is true if both dates of birth are known and agree.
+	DateOfBirthMatch bool `json:"dateOfBirthMatch"`
+}
+
+// ScreeningReport lists the matches of a screened name, best first.
+type ScreeningReport struct {
+	Subject     string           `json:"subject,omitempty"`
+	Name        string           `json:"name"`
+	DateOfBirth string           `json:"dateOfBirth,omitempty"`
+	Threshold   float64          `json:"threshold"`
+	ScreenedAt  time.Time        `json:"screenedAt"`
+	Matches     []ScreeningMatch `json:"matches"`
+}
+
+// Hit reports whether the screening found any match.
+func (r *ScreeningReport) Hit() bool {
+	return len(r.Matches) > 0
+}
+
+// Screen matches the name and the optional date of birth against every
+// entry. Names are compared after normalization and transliteration to
+// latin letters, as the best Jaro-Winkler similarity of their tokens in any
+// order, or of the whole names without spaces.
+func (w *Watchlist) Screen(name, dateOfBirth string, opts ScreeningOptions) *ScreeningReport {
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultScreeningThreshold
+	}
+	if opts.DateOfBirthPenalty <= 0 {
+		opts.DateOfBirthPenalty = DefaultDateOfBirthPenalty
+	}
+
+	report := &ScreeningReport{
+		Name:        name,
+		DateOfBirth: dateOfBirth,
+		Threshold:   opts.Threshold,
+		ScreenedAt:  time.Now().UTC(),
+		Matches:     []ScreeningMatch{},
+	}
+	tokens := nameTokens(name)
+	if len(tokens) == 0 {
+		return report
+	}
+
+	for _, entry := range w.entries {
+		best, bestName := 0.0, ""
+		for _, entryName := range entry.names {
+			if score := nameSimilarity(tokens, entryName.tokens); score > best {
+				best, bestName = score, entryName.name
+			}
+		}
+
+		known := dateOfBirth != "" && entry.DateOfBirth != ""
+		dobMatch := known && datesAgree(dateOfBirth, entry.DateOfBirth)
+		if known && !dobMatch {
+			best -= opts.DateOfBirthPenalty
+		}
+		if best < opts.Threshold {
+			continue
+		}
+		report.Matches = append(report.Matches, ScreeningMatch{
+			EntryID:          entry.ID,
+			List:             entry.List,
+			Name:             bestName,
+			Score:            float64(int(best*1000+0.5)) / 1000,
+			DateOfBirth:      entry.DateOfBirth,
+			DateOfBirthMatch: dobMatch,
+		})
+	}
+
+	sort.SliceStable(report.Matches, func(i, j int) bool {
+		return report.Matches[i].Score > report.Matches[j].Score
+	})
+	return report
+}
+
+// ScreenApplication screens the persons and the company of an application:
+// the applicant of an individual application, or the company, directors and
+// UBOs of a corporate application.
+func (w *Watchlist) ScreenApplication(content ApplicationContent, opts ScreeningOptions) []*ScreeningReport {
+	var reports []*ScreeningReport
+	screenPerson := func(subject string, p *Person) {
+		name := strings.Join([]string{p.FirstName, p.MiddleName, p.LastName}, " ")
+		report := w.Screen(name, p.DateOfBirth, opts)
+		report.Subject = subject
+		reports = append(reports, report)
+	}
+
+	switch app := content.(type) {
+	case *IndividualApplication:
+		screenPerson("applicant", &app.Person)
+	case *CorporateApplication:
+		report := w.Screen(app.CompanyName, "", opts)
+		report.Subject = "company"
+		reports = append(reports, report)
+		for i := range app.Directors {
+			screenPerson(fmt.Sprintf("directors[%d]", i), &app.Directors[i])
+		}
+		for i := range app.UBOs {
+			screenPerson(fmt.Sprintf("ubos[%d]", i), &app.UBOs[i].Person)
+		}
+	}
+	return reports
+}
+
+// ApplicationScreeningUpdate attaches the screening reports to the
+// application as its screening field with ApplicationUpdate2.
+func (k *KYC) ApplicationScreeningUpdate(applicationID string, reports []*ScreeningReport) (*Result, error) {
+	if len(applicationID) == 0 {
+		return nil, errors.New("applicationID is empty")
+	}
+
+	var list []interface{}
+	if err := decodeData(reports, &list); err != nil {
+		return nil, err
+	}
+	hit := false
+	for _, report := range reports {
+		hit = hit || report.Hit()
+	}
+	return k.ApplicationUpdate2(applicationID, map[string]interface{}{
+		"screening": map[string]interface{}{
+			"hit":     hit,
+			"reports": list,
+		},
+	})
+}
+
+// honorifics are dropped from names before matching.
+var honorifics = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "miss": true, "dr": true, "prof": true,
+	"sir": true, "madam": true, "sheikh": true, "haji": true,
+}
+
+// transliterations maps letters which do not decompose to latin letters.
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th", 'ı': "i",
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o",
+}
+
+// normalizeName lowercases the name, removes diacritics and punctuation and
+// transliterates cyrillic and greek letters.
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(strings.ToLower(name)) {
+		latin, ok := transliterations[r]
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case ok:
+			b.WriteString(latin)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '\'' || r == '’':
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return b.String()
+}
+
+func nameTokens(name string) []string {
+	var tokens []string
+	for _, token := range strings.Fields(normalizeName(name)) {
+		if !honorifics[token] {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// nameSimilarity is the best coverage of one name by the other, so a
+// dropped middle name still matches, or the similarity of the joined tokens
+// if higher. A single token name only scores the mean of both directions,
+// not to match every entry sharing a surname.
+func nameSimilarity(a, b []string) float64 {
+	forward, backward := tokenCoverage(a, b), tokenCoverage(b, a)
+	score := (forward + backward) / 2
+	if minInt(len(a), len(b)) > 1 {
+		score = math.Max(forward, backward)
+	}
+	if joined := jaroWinkler(strings.Join(a, ""), strings.Join(b, "")); joined > score {
+		score = joined
+	}
+	return score
+}
+
+func tokenCoverage(a, b []string) float64 {
+	var total float64
+	for _, ta := range a {
+		best := 0.0
+		for _, tb := range b {
+			if s := jaroWinkler(ta, tb); s > best {
+				best = s
+			}
+		}
+		if best >= 0.7 {
+			total += best
+		}
+	}
+	return total / float64(len(a))
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of two strings, from 0 to 1.
+func jaroWinkler(a, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 || len(s2) == 0 {
+		if len(s1) == len(s2) {
+			return 1
+		}
+		return 0
+	}
+
+	window := maxInt(len(s1), len(s2))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+	matches := 0
+	for i := range s1 {
+		for j := maxInt(0, i-window); j < minInt(len(s2), i+window+1); j++ {
+			if !matched2[j] && s1[i] == s2[j] {
+				matched1[i], matched2[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[j] {
+			j++
+		}
+		if s1[i] != s2[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions)/2)/m) / 3
+	if jaro < 0.7 {
+		return jaro
+	}
+	prefix := 0
+	for prefix < minInt(4, minInt(len(s1), len(s2))) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// datesAgree compares dates of birth of different precision, such as 1970
+// and 1970-05-01.
+func datesAgree(a, b string) bool {
+	n := minInt(len(a), len(b))
+	return n > 0 && a[:n] == b[:n]
+}
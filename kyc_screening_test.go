@@ -0,0 +1,96 @@
+package jadepoolsaas
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testWatchlist(t *testing.T) *Watchlist {
+	w := &Watchlist{}
+	err := w.LoadCSV(strings.NewReader(`id,name,aliases,dateOfBirth,nationality
+s1,Vladimir Petrov,Влади́мир Петро́в;V. Petrov,1965-03-02,RU
+s2,Acme Trading LLC,,,
+s3,John Michael Smith,,,
+`), "sanctions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.LoadXML(strings.NewReader(`<watchlist>
+  <entry id="p1"><name>José María Núñez</name><alias>Jose Nunez</alias><dateOfBirth>1970</dateOfBirth><list>pep</list></entry>
+</watchlist>`), "sanctions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestWatchlistScreen(t *testing.T) {
+	w := testWatchlist(t)
+	if w.Len() != 4 {
+		t.Fatalf("entries = %d; want 4", w.Len())
+	}
+
+	for _, test := range []struct {
+		name, dateOfBirth string
+		want              string
+	}{
+		{"Petrov Vladimir", "", "s1"},
+		{"Mr. Vladimir Petrow", "1965-03-02", "s1"},
+		{"Владимир Петров", "", "s1"},
+		{"Jose Maria Nunez", "1970-06-01", "p1"},
+		{"ACME trading, L.L.C.", "", "s2"},
+		{"Smith John", "", "s3"},
+		{"Jon Smith", "", "s3"},
+		{"John M. Smith", "", "s3"},
+		{"Smith", "", ""},
+		{"Alice Smith", "", ""},
+		{"Vladimir Petrow", "1990-01-01", ""},
+	} {
+		report := w.Screen(test.name, test.dateOfBirth, ScreeningOptions{})
+		got := ""
+		if report.Hit() {
+			got = report.Matches[0].EntryID
+		}
+		if got != test.want {
+			t.Errorf("Screen(%s, %s) = %+v; want %q", test.name, test.dateOfBirth, report.Matches, test.want)
+		}
+	}
+
+	report := w.Screen("Vladimir Petrow", "1990-01-01", ScreeningOptions{Threshold: 0.8})
+	if !report.Hit() || report.Matches[0].DateOfBirthMatch {
+		t.Errorf("matches = %+v; want s1 with a different date of birth under a lower threshold", report.Matches)
+	}
+}
+
+func TestApplicationScreeningUpdate(t *testing.T) {
+	var body map[string]interface{}
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		_, err := writeSuccessResponse(w, map[string]interface{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	app := testIndividualApplication()
+	app.FirstName, app.LastName, app.DateOfBirth = "José", "Núñez", "1970-01-01"
+	reports := testWatchlist(t).ScreenApplication(app, ScreeningOptions{})
+	if len(reports) != 1 || reports[0].Subject != "applicant" || !reports[0].Hit() {
+		t.Fatalf("reports = %+v; want a hit for the applicant", reports)
+	}
+
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret)
+	if _, err := checkResult(kyc.ApplicationScreeningUpdate("a1", reports)); err != nil {
+		t.Fatal(err)
+	}
+	screening, _ := body["screening"].(map[string]interface{})
+	list, _ := screening["reports"].([]interface{})
+	if screening["hit"] != true || len(list) != 1 {
+		t.Errorf("body = %v; want screening hit with a report", body)
+	}
+}
@@ -0,0 +1,69 @@
+package jadepoolsaas
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ApplicationStatus is the review status of an application.
+type ApplicationStatus string
+
+// Application statuses.
+const (
+	StatusDraft     ApplicationStatus = "draft"
+	StatusSubmitted ApplicationStatus = "submitted"
+	StatusApproved  ApplicationStatus = "approved"
+	StatusRejected  ApplicationStatus = "rejected"
+	StatusNeedsInfo ApplicationStatus = "needs-info"
+)
+
+var statusAliases = map[string]ApplicationStatus{
+	"draft":     StatusDraft,
+	"created":   StatusDraft,
+	"editing":   StatusDraft,
+	"submitted": StatusSubmitted,
+	"pending":   StatusSubmitted,
+	"reviewing": StatusSubmitted,
+	"inreview":  StatusSubmitted,
+	"approved":  StatusApproved,
+	"accepted":  StatusApproved,
+	"passed":    StatusApproved,
+	"rejected":  StatusRejected,
+	"declined":  StatusRejected,
+	"denied":    StatusRejected,
+	"needsinfo": StatusNeedsInfo,
+	"needinfo":  StatusNeedsInfo,
+	"returned":  StatusNeedsInfo,
+	"resubmit":  StatusNeedsInfo,
+}
+
+// ParseApplicationStatus normalizes a status returned by the server, unknown
+// statuses are returned unchanged.
+func ParseApplicationStatus(status string) ApplicationStatus {
+	key := strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(status))
+	if s, ok := statusAliases[key]; ok {
+		return s
+	}
+	return ApplicationStatus(status)
+}
+
+// Final reports whether the status ends the review.
+func (s ApplicationStatus) Final() bool {
+	return s == StatusApproved || s == StatusRejected
+}
+
+// applicationID returns the id of the application in the data of a result.
+func applicationID(data map[string]interface{}) string {
+	for _, key := range []string{"id", "applicationID"} {
+		if id, ok := data[key]; ok && id != nil {
+			return fmt.Sprint(id)
+		}
+	}
+	return ""
+}
+
+// applicationStatus returns the status of the application in the data of a result.
+func applicationStatus(data map[string]interface{}) ApplicationStatus {
+	status, _ := data["status"].(string)
+	return ParseApplicationStatus(status)
+}
@@ -0,0 +1,260 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default polling intervals of a KYCWatcher.
+const (
+	DefaultWatchMinInterval = 30 * time.Second
+	DefaultWatchMaxInterval = 10 * time.Minute
+)
+
+// StatusChange is a status transition of a watched application.
+type StatusChange struct {
+	ApplicationID string
+	// From is empty on the first poll of the application.
+	From     ApplicationStatus
+	To       ApplicationStatus
+	Comments []string
+	Time     time.Time
+}
+
+// KYCWatcher polls the status of applications with ApplicationGet. An
+// application is polled every MinInterval after it changed, and the interval
+// doubles up to MaxInterval while it does not. Applications are no longer
+// watched once their status is final.
+type KYCWatcher struct {
+	KYC         *KYC
+	MinInterval time.Duration
+	MaxInterval time.Duration
+	// OnChange is called with every status change, including the first
+	// status polled.
+	OnChange func(change *StatusChange)
+	// OnError is called when polling an application failed, it is polled
+	// again after the next interval.
+	OnError func(applicationID string, err error)
+
+	mu      sync.Mutex
+	watched map[string]*watchedApplication
+	wake    chan struct{}
+}
+
+type watchedApplication struct {
+	status   ApplicationStatus
+	interval time.Duration
+	next     time.Time
+}
+
+// NewKYCWatcher creates a watcher calling onChange with status changes.
+func NewKYCWatcher(kyc *KYC, onChange func(change *StatusChange)) *KYCWatcher {
+	return &KYCWatcher{
+		KYC:         kyc,
+		MinInterval: DefaultWatchMinInterval,
+		MaxInterval: DefaultWatchMaxInterval,
+		OnChange:    onChange,
+	}
+}
+
+// Watch adds applications to poll, they are polled right away.
+func (w *KYCWatcher) Watch(applicationIDs ...string) {
+	w.mu.Lock()
+	w.init()
+	for _, id := range applicationIDs {
+		if _, ok := w.watched[id]; !ok {
+			w.watched[id] = &watchedApplication{interval: w.MinInterval}
+		}
+	}
+	w.mu.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Unwatch stops polling applications.
+func (w *KYCWatcher) Unwatch(applicationIDs ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, id := range applicationIDs {
+		delete(w.watched, id)
+	}
+}
+
+// Watching returns the sorted ids of the watched applications.
+func (w *KYCWatcher) Watching() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ids := make([]string, 0, len(w.watched))
+	for id := range w.watched {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Run polls the watched applications until ctx is done.
+func (w *KYCWatcher) Run(ctx context.Context) error {
+	if w.KYC == nil {
+		return errors.New("kyc is nil")
+	}
+	w.mu.Lock()
+	w.init()
+	wake := w.wake
+	w.mu.Unlock()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		case <-timer.C:
+		}
+
+		next := w.pollDue(ctx)
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(next)
+	}
+}
+
+func (w *KYCWatcher) init() {
+	if w.watched == nil {
+		w.watched = make(map[string]*watchedApplication)
+		w.wake = make(chan struct{}, 1)
+	}
+	if w.MinInterval <= 0 {
+		w.MinInterval = DefaultWatchMinInterval
+	}
+	if w.MaxInterval < w.MinInterval {
+		w.MaxInterval = w.MinInterval
+	}
+}
+
+// pollDue polls the applications due and returns the delay until the next one.
+func (w *KYCWatcher) pollDue(ctx context.Context) time.Duration {
+	now := time.Now()
+	var due []string
+	w.mu.Lock()
+	for id, app := range w.watched {
+		if !app.next.After(now) {
+			due = append(due, id)
+		}
+	}
+	w.mu.Unlock()
+	sort.Strings(due)
+
+	kyc := w.KYC.WithContext(ctx)
+	for _, id := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		w.poll(kyc, id)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	next := w.MaxInterval
+	now = time.Now()
+	for _, app := range w.watched {
+		if wait := app.next.Sub(now); wait < next {
+			next = wait
+		}
+	}
+	if next < 0 {
+		next = 0
+	}
+	return next
+}
+
+func (w *KYCWatcher) poll(kyc *KYC, id string) {
+	ret, err := checkResult(kyc.ApplicationGet(id, false))
+	var status ApplicationStatus
+	if err == nil {
+		if status = applicationStatus(ret.Data); status == "" {
+			err = fmt.Errorf("status of application %s is missing in the result", id)
+		}
+	}
+
+	w.mu.Lock()
+	app, ok := w.watched[id]
+	if !ok {
+		w.mu.Unlock()
+		return
+	}
+	var change *StatusChange
+	switch {
+	case err != nil:
+		app.interval = w.backoff(app.interval)
+	case status != app.status:
+		change = &StatusChange{
+			ApplicationID: id,
+			From:          app.status,
+			To:            status,
+			Comments:      reviewerComments(ret.Data),
+			Time:          time.Now(),
+		}
+		app.status = status
+		app.interval = w.MinInterval
+	default:
+		app.interval = w.backoff(app.interval)
+	}
+	app.next = time.Now().Add(app.interval)
+	if status.Final() {
+		delete(w.watched, id)
+	}
+	w.mu.Unlock()
+
+	if err != nil && w.OnError != nil {
+		w.OnError(id, err)
+	}
+	if change != nil && w.OnChange != nil {
+		w.OnChange(change)
+	}
+}
+
+func (w *KYCWatcher) backoff(interval time.Duration) time.Duration {
+	interval *= 2
+	if interval > w.MaxInterval {
+		return w.MaxInterval
+	}
+	return interval
+}
+
+// reviewerComments returns the comments of the reviewers in the data of an
+// application, from a comments list of strings or objects, or a single
+// comment field.
+func reviewerComments(data map[string]interface{}) []string {
+	var comments []string
+	if list, ok := data["comments"].([]interface{}); ok {
+		for _, item := range list {
+			switch t := item.(type) {
+			case string:
+				comments = append(comments, t)
+			case map[string]interface{}:
+				if text := stringField(t, "content", "comment", "message", "text"); text != "" {
+					comments = append(comments, text)
+				}
+			}
+		}
+	}
+	for _, key := range []string{"comment", "reviewComment", "reason", "rejectReason"} {
+		if text, ok := data[key].(string); ok && text != "" {
+			comments = append(comments, text)
+		}
+	}
+	return comments
+}
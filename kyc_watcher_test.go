@@ -0,0 +1,81 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKYCWatcher(t *testing.T) {
+	var mu sync.Mutex
+	polls := make(map[string]int)
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/api/v1/application/")
+		mu.Lock()
+		polls[id]++
+		n := polls[id]
+		mu.Unlock()
+
+		data := map[string]interface{}{"id": id, "status": "pending"}
+		switch {
+		case id == "a2" && n == 1:
+			writeErrorResponse(w, 500, "unavailable")
+			return
+		case id == "a1" && n >= 3:
+			data["status"] = "approved"
+			data["comments"] = []interface{}{map[string]interface{}{"content": "documents verified"}}
+		}
+
+		_, err := writeSuccessResponse(w, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var changes []*StatusChange
+	var errs []string
+	watcher := NewKYCWatcher(NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret), func(change *StatusChange) {
+		changes = append(changes, change)
+		if change.To == StatusApproved {
+			cancel()
+		}
+	})
+	watcher.MinInterval = 10 * time.Millisecond
+	watcher.MaxInterval = 20 * time.Millisecond
+	watcher.OnError = func(applicationID string, err error) {
+		errs = append(errs, applicationID)
+	}
+	watcher.Watch("a1", "a2")
+
+	if err := watcher.Run(ctx); err != context.Canceled {
+		t.Fatalf("err = %v; want canceled", err)
+	}
+
+	if len(errs) != 1 || errs[0] != "a2" {
+		t.Errorf("errors = %v; want a2 once", errs)
+	}
+	var a1 []*StatusChange
+	for _, change := range changes {
+		if change.ApplicationID == "a1" {
+			a1 = append(a1, change)
+		}
+	}
+	if len(a1) != 2 || a1[0].From != "" || a1[0].To != StatusSubmitted || a1[1].From != StatusSubmitted || a1[1].To != StatusApproved {
+		t.Fatalf("changes of a1 = %+v; want submitted then approved", a1)
+	}
+	if len(a1[1].Comments) != 1 || a1[1].Comments[0] != "documents verified" {
+		t.Errorf("comments = %v; want documents verified", a1[1].Comments)
+	}
+	if watching := watcher.Watching(); len(watching) != 1 || watching[0] != "a2" {
+		t.Errorf("watching = %v; want only a2 after a1 approved", watching)
+	}
+}
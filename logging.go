@@ -0,0 +1,54 @@
+package jadepoolsaas
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Logging logs every call with duration and status. Successful calls are
+// logged at level and failed calls at slog.LevelError. Secrets, signatures
+// and kyc personal data are always redacted, extra fields can be specified.
+func Logging(logger *slog.Logger, level slog.Level, redactFields ...string) Interceptor {
+	r := newRedactor(secretFields, personalFields, redactFields)
+	return func(next RoundTrip) RoundTrip {
+		return func(call *Call) error {
+			start := time.Now()
+			err := next(call)
+
+			callLevel := level
+			if err != nil {
+				callLevel = slog.LevelError
+			}
+			ctx := call.Context()
+			if !logger.Enabled(ctx, callLevel) {
+				return err
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", call.Method),
+				slog.String("endpoint", call.Endpoint),
+				slog.String("path", r.path(call)),
+				slog.Any("params", r.value(call.Params)),
+				slog.Int("status", call.StatusCode),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if call.Result != nil {
+				attrs = append(attrs,
+					slog.Int("code", call.Result.Code),
+					slog.String("message", call.Result.Message),
+					slog.Any("data", r.value(call.Result.Data)),
+				)
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			logger.LogAttrs(ctx, callLevel, "jadepool request", attrs...)
+			return err
+		}
+	}
+}
+
+// WithLogger logs every call of the client, see Logging.
+func WithLogger(logger *slog.Logger, level slog.Level, redactFields ...string) Option {
+	return WithInterceptors(Logging(logger, level, redactFields...))
+}
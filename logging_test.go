@@ -0,0 +1,102 @@
+package jadepoolsaas
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogging(t *testing.T) {
+	response := map[string]interface{}{
+		"id":        "1",
+		"firstName": "Alice",
+	}
+
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		_, err := writeSuccessResponse(w, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret, WithLogger(logger, slog.LevelInfo, "taxNumber"))
+	_, err := kyc.ApplicationUpdate2("1", map[string]interface{}{
+		"firstName":  "Alice",
+		"taxNumber":  "123-45-6789",
+		"occupation": "engineer",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"Alice", "123-45-6789", TestAppKey} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("log contains %s", secret)
+		}
+	}
+
+	var record map[string]interface{}
+	if err = json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	params := record["params"].(map[string]interface{})
+	if params["sign"] != redactedValue || params["occupation"] != "engineer" {
+		t.Errorf("params = %v; want sign redacted and occupation kept", params)
+	}
+	if record["status"] != float64(200) || record["path"] != "/api/v1/application/1" {
+		t.Errorf("record = %v; want status 200 and application path", record)
+	}
+	if _, ok := record["duration"]; !ok {
+		t.Error("duration is missing")
+	}
+}
+
+func TestLoggingRedactsKYCData(t *testing.T) {
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		_, err := writeSuccessResponse(w, map[string]interface{}{"id": "fiat1", "iban": "GB82WEST12345698765432"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret, WithLogger(logger, slog.LevelInfo))
+	if _, err := kyc.ApplicationGetByIdentifier(ApplicationTypeIndividual, "alice@example.com", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kyc.FiatCreate("a1", map[string]interface{}{
+		"accountHolder": "Alice Smith",
+		"iban":          "GB82WEST12345698765432",
+		"currency":      "GBP",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kyc.JumioPost("a1", map[string]interface{}{
+		"idFirstName": "ALICE",
+		"idLastName":  "SMITHSON",
+		"idDob":       "1990-01-31",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"alice@example.com", "Alice Smith", "GB82WEST12345698765432", "ALICE", "SMITHSON", "1990-01-31"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("log contains %s", secret)
+		}
+	}
+	if !strings.Contains(buf.String(), `"path":"/api/v1/application/identifier/individual/REDACTED"`) ||
+		!strings.Contains(buf.String(), `"endpoint":"kyc.application.getByIdentifier"`) {
+		t.Errorf("log = %s; want endpoint and redacted identifier path", buf.String())
+	}
+}
@@ -0,0 +1,111 @@
+// Package metrics instruments sdk calls with prometheus metrics.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	sdk "github.com/nbltrust/hashkey-custody-sdk-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector collects request counters, latencies, errors, in-flight requests
+// and the clock skew measured against the server of every instrumented client.
+type Collector struct {
+	requests  *prometheus.CounterVec
+	errors    *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+	inFlight  prometheus.Gauge
+	clockSkew prometheus.Gauge
+}
+
+// NewCollector creates a collector, metric names are prefixed with namespace.
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "jadepool",
+			Name:      "requests_total",
+			Help:      "Total number of api calls.",
+		}, []string{"endpoint", "method", "code"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "jadepool",
+			Name:      "errors_total",
+			Help:      "Total number of failed api calls.",
+		}, []string{"endpoint", "method", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "jadepool",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of api calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "method"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "jadepool",
+			Name:      "in_flight_requests",
+			Help:      "Number of api calls in progress.",
+		}),
+		clockSkew: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "jadepool",
+			Name:      "clock_skew_seconds",
+			Help:      "Server time minus local time, measured from the Date header of the last response.",
+		}),
+	}
+}
+
+// Interceptor returns the interceptor recording metrics of every call.
+func (c *Collector) Interceptor() sdk.Interceptor {
+	return func(next sdk.RoundTrip) sdk.RoundTrip {
+		return func(call *sdk.Call) error {
+			c.inFlight.Inc()
+			defer c.inFlight.Dec()
+
+			start := time.Now()
+			err := next(call)
+			duration := time.Since(start)
+
+			code := ""
+			if call.Result != nil {
+				code = strconv.Itoa(call.Result.Code)
+			}
+			c.requests.WithLabelValues(call.Endpoint, call.Method, code).Inc()
+			c.latency.WithLabelValues(call.Endpoint, call.Method).Observe(duration.Seconds())
+			if err != nil || (call.Result != nil && call.Result.Code != 0) {
+				c.errors.WithLabelValues(call.Endpoint, call.Method, code).Inc()
+			}
+
+			if date, err := http.ParseTime(call.ResponseHeader.Get("Date")); err == nil {
+				local := start.Add(duration / 2)
+				c.clockSkew.Set(date.Sub(local).Seconds())
+			}
+			return err
+		}
+	}
+}
+
+// Option instruments a client with the collector.
+func (c *Collector) Option() sdk.Option {
+	return sdk.WithInterceptors(c.Interceptor())
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.errors.Describe(ch)
+	c.latency.Describe(ch)
+	c.inFlight.Describe(ch)
+	c.clockSkew.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.errors.Collect(ch)
+	c.latency.Collect(ch)
+	c.inFlight.Collect(ch)
+	c.clockSkew.Collect(ch)
+}
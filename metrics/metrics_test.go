@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	sdk "github.com/nbltrust/hashkey-custody-sdk-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const (
+	testKey    = "gznXiKEInYdAiITtk55KyUk3"
+	testSecret = "gZJHdgNYlywjdS815T8feXoPfmY9K6KCBRuPs8q3f2tvEWnzN5S58OJjRraY5YQE"
+)
+
+func TestCollector(t *testing.T) {
+	cassette := &sdk.Cassette{Interactions: []*sdk.Interaction{
+		{
+			Request: sdk.RecordedRequest{Method: "GET", Path: "/api/v1/app/balance/ETH"},
+			Response: sdk.RecordedResponse{
+				StatusCode: 200,
+				Header:     map[string]string{"Date": time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)},
+				Body:       `{"code":0,"data":{},"message":"success","sign":""}`,
+			},
+		},
+		{
+			Request: sdk.RecordedRequest{Method: "GET", Path: "/api/v1/app/balance/BTC"},
+			Response: sdk.RecordedResponse{
+				StatusCode: 500,
+				Body:       "internal error",
+			},
+		},
+	}}
+	httpClient := &http.Client{Transport: sdk.NewReplayerWithCassette(cassette, testSecret)}
+
+	collector := NewCollector("test")
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	app := sdk.NewAppWithAddr("http://127.0.0.1", testKey, testSecret, sdk.WithHTTPClient(httpClient), collector.Option())
+	if _, err := app.GetBalance("ETH"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.GetBalance("BTC"); err == nil {
+		t.Fatal("want http error")
+	}
+
+	if v := testutil.ToFloat64(collector.requests.WithLabelValues("app.balance", "GET", "0")); v != 1 {
+		t.Errorf("requests = %v; want 1", v)
+	}
+	if v := testutil.ToFloat64(collector.errors.WithLabelValues("app.balance", "GET", "")); v != 1 {
+		t.Errorf("errors = %v; want 1", v)
+	}
+	if v := testutil.ToFloat64(collector.inFlight); v != 0 {
+		t.Errorf("in flight = %v; want 0", v)
+	}
+	if v := testutil.ToFloat64(collector.clockSkew); v < 50 || v > 70 {
+		t.Errorf("clock skew = %v; want about 60", v)
+	}
+	if n := testutil.CollectAndCount(collector, "test_jadepool_request_duration_seconds"); n != 1 {
+		t.Errorf("latency series = %d; want 1", n)
+	}
+}
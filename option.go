@@ -0,0 +1,33 @@
+package jadepoolsaas
+
+import "net/http"
+
+// Option configures the session of an App, Company or KYC instance.
+type Option func(*session)
+
+// WithHTTPClient sends all requests through the specified http client.
+func WithHTTPClient(c *http.Client) Option {
+	return func(s *session) {
+		s.httpClient = c
+	}
+}
+
+// WithNotFoundCodes sets the result codes the server returns for a resource
+// which does not exist. Without them, only a 404 http status is considered a
+// missing resource, such as an application looked up before it is created.
+func WithNotFoundCodes(codes ...int) Option {
+	return func(s *session) {
+		s.notFoundCodes = codes
+	}
+}
+
+func newSession(c client, opts []Option) *session {
+	s := &session{client: c, nonceCount: new(int64)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.failover != nil {
+		s.failover.shareClient(s.httpClient)
+	}
+	return s
+}
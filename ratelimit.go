@@ -0,0 +1,84 @@
+package jadepoolsaas
+
+import (
+	"strings"
+
+	"golang.org/x/time/rate"
+)
+
+// Limit configures the rate and concurrency of calls.
+type Limit struct {
+	// Rate is the number of calls allowed per second, 0 means unlimited.
+	Rate float64
+	// Burst is the maximum number of calls allowed at once, defaults to 1.
+	Burst int
+	// MaxInFlight caps the number of concurrent calls, 0 means unlimited.
+	MaxInFlight int
+}
+
+// RateLimit blocks calls exceeding the limit until they are allowed or the
+// call's context is done. If groups are specified, such as "address",
+// "withdraw" or "otc", only calls to endpoints of those groups are limited.
+// Share the interceptor between clients to limit them together, e.g. per api key.
+func RateLimit(limit Limit, groups ...string) Interceptor {
+	var limiter *rate.Limiter
+	if limit.Rate > 0 {
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(rate.Limit(limit.Rate), burst)
+	}
+
+	var sem chan struct{}
+	if limit.MaxInFlight > 0 {
+		sem = make(chan struct{}, limit.MaxInFlight)
+	}
+
+	return func(next RoundTrip) RoundTrip {
+		return func(call *Call) error {
+			if len(groups) > 0 && !containsString(groups, endpointGroup(call.Endpoint)) {
+				return next(call)
+			}
+
+			ctx := call.Context()
+			if sem != nil {
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			if limiter != nil {
+				if err := limiter.Wait(ctx); err != nil {
+					return err
+				}
+			}
+			return next(call)
+		}
+	}
+}
+
+// WithRateLimit limits the calls of the client, see RateLimit.
+func WithRateLimit(limit Limit, groups ...string) Option {
+	return WithInterceptors(RateLimit(limit, groups...))
+}
+
+// endpointGroup returns the group of the endpoint, "app.address.create" is in group "address".
+func endpointGroup(endpoint string) string {
+	parts := strings.SplitN(endpoint, ".", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	response := map[string]interface{}{}
+
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		_, err := writeSuccessResponse(w, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	app := NewAppWithAddr(ts.URL, TestAppKey, TestAppSecret, WithRateLimit(Limit{Rate: 20}, "address"))
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := app.CreateAddress("ETH"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("elapsed = %v; want >= 100ms", elapsed)
+	}
+
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := app.GetBalance("ETH"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 90*time.Millisecond {
+		t.Errorf("elapsed = %v; want calls outside the group not limited", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	app.CreateAddress("ETH")
+	if _, err := app.WithContext(ctx).CreateAddress("ETH"); err == nil {
+		t.Error("want context error while waiting for the limiter")
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	response := map[string]interface{}{}
+
+	var inFlight, maxInFlight int32
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		_, err := writeSuccessResponse(w, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	app := NewAppWithAddr(ts.URL, TestAppKey, TestAppSecret, WithRateLimit(Limit{MaxInFlight: 2}))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := app.GetBalance("ETH"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight > 2 {
+		t.Errorf("max in flight = %d; want <= 2", maxInFlight)
+	}
+}
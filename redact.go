@@ -0,0 +1,124 @@
+package jadepoolsaas
+
+import (
+	"net/http"
+	"strings"
+)
+
+const redactedValue = "REDACTED"
+
+// secretFields hold credentials and signatures.
+var secretFields = []string{
+	"sign",
+	"secret",
+	"appSecret",
+	"encryptedAppSecret",
+	"password",
+	"X-App-Key",
+	"X-Company-Key",
+	"X-API-Key",
+}
+
+// personalFields hold kyc personal data.
+var personalFields = []string{
+	"firstName",
+	"middleName",
+	"lastName",
+	"fullName",
+	"birthday",
+	"dateOfBirth",
+	"idNumber",
+	"documentNumber",
+	"passportNumber",
+	"nationality",
+	"address",
+	"email",
+	"phone",
+	"mobile",
+	"identifier",
+	"companyName",
+	"registrationNumber",
+	"accountHolder",
+	"accountNumber",
+	"iban",
+	"idFirstName",
+	"idLastName",
+	"idDob",
+	"idExpiry",
+}
+
+// redactor replaces the values of sensitive fields, field names are case insensitive.
+type redactor map[string]bool
+
+func newRedactor(fieldLists ...[]string) redactor {
+	r := make(redactor)
+	for _, fields := range fieldLists {
+		for _, field := range fields {
+			r[strings.ToLower(field)] = true
+		}
+	}
+	return r
+}
+
+func (r redactor) header(header http.Header) map[string]string {
+	ret := make(map[string]string, len(header))
+	for key := range header {
+		if r[strings.ToLower(key)] {
+			ret[key] = redactedValue
+		} else {
+			ret[key] = header.Get(key)
+		}
+	}
+	return ret
+}
+
+// path returns the path of the call with the values of sensitive path
+// variables replaced, such as the identifier of an application.
+func (r redactor) path(call *Call) string {
+	masked := make(map[string]bool)
+	for name, value := range call.vars {
+		if r[strings.ToLower(name)] && value != "" {
+			masked[value] = true
+		}
+	}
+	if len(masked) == 0 {
+		return call.Path
+	}
+
+	segments := strings.Split(call.Path, "/")
+	for i, segment := range segments {
+		if masked[segment] {
+			segments[i] = redactedValue
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (r redactor) value(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if r[strings.ToLower(key)] {
+				ret[key] = redactedValue
+			} else {
+				ret[key] = r.value(item)
+			}
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, item := range v {
+			ret[i] = r.value(item)
+		}
+		return ret
+	case []map[string]interface{}:
+		ret := make([]interface{}, len(v))
+		for i, item := range v {
+			ret[i] = r.value(item)
+		}
+		return ret
+	default:
+		return v
+	}
+}
@@ -2,10 +2,14 @@ package jadepoolsaas
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/imroc/req"
@@ -21,9 +25,27 @@ type Result struct {
 	Sign    string
 }
 
+// Decode converts the data of the result into v, such as an IndividualApplication.
+func (result *Result) Decode(v interface{}) error {
+	return decodeData(result.Data, v)
+}
+
 type session struct {
-	client     client
-	nonceCount int
+	client       client
+	nonceCount   *int64
+	httpClient   *http.Client
+	interceptors []Interceptor
+	failover     *Failover
+	ctx          context.Context
+	// notFoundCodes are the result codes of a resource which does not exist.
+	notFoundCodes []int
+}
+
+func (session *session) withContext(c client, ctx context.Context) *session {
+	copied := *session
+	copied.client = c
+	copied.ctx = ctx
+	return &copied
 }
 
 func (session *session) get(path string) (*Result, error) {
@@ -31,51 +53,29 @@ func (session *session) get(path string) (*Result, error) {
 }
 
 func (session *session) getWithParams(path string, params params) (*Result, error) {
-	url := session.getURL(path)
-	err := session.prepareParams(params)
+	call, err := session.newCall("GET", path, params)
 	if err != nil {
 		return nil, err
 	}
 
-	r, err := req.Get(url, session.commonHeaders(), req.Param(params))
-	if err != nil {
-		return nil, err
-	}
-	if r.Response().StatusCode != 200 {
-		return nil, fmt.Errorf("http error code:%d", r.Response().StatusCode)
-	}
-
-	var result Result
-	err = r.ToJSON(&result)
-	if err != nil {
-		return nil, fmt.Errorf("parse body to json failed: %v", err)
-	}
-
-	if err = result.error(session.client.getSecret()); err != nil {
-		return &result, err
-	}
-
-	return &result, err
+	err = session.roundTrip(call)
+	return call.Result, err
 }
 
 func (session *session) getFile(path string, filePath string) (*Result, error) {
-	params := params{}
-
-	url := session.getURL(path)
-	err := session.prepareParams(params)
+	call, err := session.newCall("GET", path, params{})
 	if err != nil {
 		return nil, err
 	}
+	call.raw = true
 
-	r, err := req.Get(url, session.commonHeaders(), req.Param(params))
-	if err != nil {
+	if err = session.roundTrip(call); err != nil {
 		return nil, err
 	}
-	if r.Response().StatusCode != 200 {
-		return nil, fmt.Errorf("http error code:%d", r.Response().StatusCode)
-	}
 
-	err = r.ToFile(filePath)
+	response := call.resp.Response()
+	defer response.Body.Close()
+	err = writeVerifiedFile(filePath, response.Body, headerDigest(response.Header))
 	if err != nil {
 		return nil, err
 	}
@@ -88,203 +88,181 @@ func (session *session) getFile(path string, filePath string) (*Result, error) {
 }
 
 func (session *session) getFile2(path string, params params) (*req.Resp, error) {
-	url := session.getURL(path)
-	err := session.prepareParams(params)
+	call, err := session.newCall("GET", path, params)
 	if err != nil {
 		return nil, err
 	}
+	call.raw = true
 
-	r, err := req.Get(url, session.commonHeaders(), req.Param(params))
-	if err != nil {
+	if err = session.roundTrip(call); err != nil {
 		return nil, err
 	}
-	if r.Response().StatusCode != 200 {
-		return nil, fmt.Errorf("http error code:%d", r.Response().StatusCode)
-	}
-
-	return r, nil
+	return call.resp, nil
 }
 
 func (session *session) post(path string, params params) (*Result, error) {
-	url := session.getURL(path)
-	err := session.prepareParams(params)
-	if err != nil {
-		return nil, err
-	}
+	return session.send("POST", path, params)
+}
 
-	r, err := req.Post(url, session.commonHeaders(), req.BodyJSON(&params))
+func (session *session) patch(path string, params params) (*Result, error) {
+	return session.send("PATCH", path, params)
+}
+
+func (session *session) postFile(path string, filePath string) (*Result, error) {
+	digest, err := fileDigest(filePath)
 	if err != nil {
 		return nil, err
 	}
-	if r.Response().StatusCode != 200 {
-		return nil, fmt.Errorf("http error code:%d", r.Response().StatusCode)
-	}
 
-	var result Result
-	err = r.ToJSON(&result)
+	call, err := session.newCall("POST", path, params{
+		"sha256": digest,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("parse body to json failed: %v", err)
+		return nil, err
 	}
+	call.body = req.File(filePath)
 
-	if err = result.error(session.client.getSecret()); err != nil {
+	if err = session.roundTrip(call); err != nil {
 		return nil, err
 	}
-
-	return &result, err
+	return call.Result, nil
 }
 
-func (session *session) patch(path string, params params) (*Result, error) {
-	url := session.getURL(path)
-	err := session.prepareParams(params)
+func (session *session) postFile2(path, fileName string, file *bytes.Reader, params params) (*Result, error) {
+	digest, content, cleanup, err := digestReader(file)
 	if err != nil {
 		return nil, err
 	}
+	defer cleanup()
+	params["sha256"] = digest
+
+	return session.postReader(path, fileName, content, params)
+}
 
-	r, err := req.Patch(url, session.commonHeaders(), req.BodyJSON(&params))
+func (session *session) postReader(path, fileName string, file io.Reader, params params) (*Result, error) {
+	call, err := session.newCall("POST", path, params)
 	if err != nil {
 		return nil, err
 	}
-	if r.Response().StatusCode != 200 {
-		return nil, fmt.Errorf("http error code:%d", r.Response().StatusCode)
-	}
-
-	var result Result
-	err = r.ToJSON(&result)
-	if err != nil {
-		return nil, fmt.Errorf("parse body to json failed: %v", err)
+	call.body = req.FileUpload{
+		FileName:  fileName,
+		FieldName: "file",
+		File:      ioutil.NopCloser(file),
 	}
 
-	if err = result.error(session.client.getSecret()); err != nil {
+	if err = session.roundTrip(call); err != nil {
 		return nil, err
 	}
+	return call.Result, nil
+}
 
-	return &result, err
+func (session *session) put(path string, params params) (*Result, error) {
+	return session.send("PUT", path, params)
 }
 
-func (session *session) postFile(path string, filePath string) (*Result, error) {
-	params := params{}
+func (session *session) delete(path string) (*Result, error) {
+	return session.deleteWithParams(path, map[string]interface{}{})
+}
 
-	url := session.getURL(path)
-	err := session.prepareParams(params)
-	if err != nil {
-		return nil, err
-	}
+func (session *session) deleteWithParams(path string, params params) (*Result, error) {
+	return session.send("DELETE", path, params)
+}
 
-	r, err := req.Post(url, session.commonHeaders(), req.File(filePath), req.Param(params))
+func (session *session) send(method, path string, params params) (*Result, error) {
+	call, err := session.newCall(method, path, params)
 	if err != nil {
 		return nil, err
 	}
-	if r.Response().StatusCode != 200 {
-		return nil, fmt.Errorf("http error code:%d", r.Response().StatusCode)
-	}
-
-	var result Result
-	err = r.ToJSON(&result)
-	if err != nil {
-		return nil, fmt.Errorf("parse body to json failed: %v", err)
-	}
 
-	if err = result.error(session.client.getSecret()); err != nil {
+	if err = session.roundTrip(call); err != nil {
 		return nil, err
 	}
-
-	return &result, err
+	return call.Result, nil
 }
 
-func (session *session) postFile2(path, fileName string, file *bytes.Reader, params params) (*Result, error) {
-	url := session.getURL(path)
+func (session *session) newCall(method, path string, params params) (*Call, error) {
 	err := session.prepareParams(params)
 	if err != nil {
 		return nil, err
 	}
 
-	r, err := req.Post(url, session.commonHeaders(), req.FileUpload{
-		FileName:  fileName,
-		FieldName: "file",
-		File:      ioutil.NopCloser(file),
-	}, req.Param(params))
-	if err != nil {
-		return nil, err
-	}
-	if r.Response().StatusCode != 200 {
-		return nil, fmt.Errorf("http error code:%d", r.Response().StatusCode)
-	}
-
-	var result Result
-	err = r.ToJSON(&result)
-	if err != nil {
-		return nil, fmt.Errorf("parse body to json failed: %v", err)
+	header := make(http.Header)
+	for key, value := range session.commonHeaders() {
+		header.Set(key, value)
 	}
 
-	if err = result.error(session.client.getSecret()); err != nil {
-		return nil, err
+	ctx := session.ctx
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	return &result, err
+	endpoint, vars := resolveEndpoint(session.client.getKind(), method, path)
+	return &Call{
+		Method:   method,
+		Path:     path,
+		Endpoint: endpoint,
+		Params:   params,
+		Header:   header,
+		ctx:      ctx,
+		vars:     vars,
+	}, nil
 }
 
-func (session *session) put(path string, params params) (*Result, error) {
-	url := session.getURL(path)
-	err := session.prepareParams(params)
-	if err != nil {
-		return nil, err
-	}
-
-	r, err := req.Put(url, session.commonHeaders(), req.BodyJSON(&params))
-	if err != nil {
-		return nil, err
-	}
-	if r.Response().StatusCode != 200 {
-		return nil, fmt.Errorf("http error code:%d", r.Response().StatusCode)
-	}
-
-	var result Result
-	err = r.ToJSON(&result)
-	if err != nil {
-		return nil, fmt.Errorf("parse body to json failed: %v", err)
+func (session *session) roundTrip(call *Call) error {
+	rt := session.do
+	for i := len(session.interceptors) - 1; i >= 0; i-- {
+		rt = session.interceptors[i](rt)
 	}
+	return rt(call)
+}
 
-	if err = result.error(session.client.getSecret()); err != nil {
-		return nil, err
+// do sends the call over the wire, it is the innermost RoundTrip of the chain.
+func (session *session) do(call *Call) error {
+	if session.failover != nil {
+		return session.failover.do(call, session.doAt)
 	}
-
-	return &result, err
+	return session.doAt(session.client.getAddr(), call)
 }
 
-func (session *session) delete(path string) (*Result, error) {
-	return session.deleteWithParams(path, map[string]interface{}{})
-}
+func (session *session) doAt(addr string, call *Call) error {
+	call.StatusCode = 0
+	call.ResponseHeader = nil
+	call.Result = nil
 
-func (session *session) deleteWithParams(path string, params params) (*Result, error) {
-	url := session.getURL(path)
-	err := session.prepareParams(params)
-	if err != nil {
-		return nil, err
+	url := fmt.Sprintf("%s%s", addr, call.Path)
+	args := []interface{}{call.ctx, call.Header, session.httpClient}
+	switch {
+	case call.body != nil:
+		args = append(args, call.body, req.Param(call.Params))
+	case call.Method == "GET" || call.Method == "DELETE":
+		args = append(args, req.QueryParam(call.Params))
+	default:
+		args = append(args, req.BodyJSON(&call.Params))
 	}
 
-	r, err := req.Delete(url, session.commonHeaders(), req.QueryParam(params))
+	r, err := req.Do(call.Method, url, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if r.Response().StatusCode != 200 {
-		return nil, fmt.Errorf("http error code:%d", r.Response().StatusCode)
+	call.StatusCode = r.Response().StatusCode
+	call.ResponseHeader = r.Response().Header
+	if call.StatusCode != 200 {
+		return &HTTPError{StatusCode: call.StatusCode}
+	}
+
+	if call.raw {
+		call.resp = r
+		return nil
 	}
 
 	var result Result
 	err = r.ToJSON(&result)
 	if err != nil {
-		return nil, fmt.Errorf("parse body to json failed: %v", err)
+		return fmt.Errorf("parse body to json failed: %v", err)
 	}
+	call.Result = &result
 
-	if err = result.error(session.client.getSecret()); err != nil {
-		return nil, err
-	}
-
-	return &result, err
-}
-
-func (session *session) getURL(path string) string {
-	return fmt.Sprintf("%s%s", session.client.getAddr(), path)
+	return result.error(session.client.getSecret())
 }
 
 func (session *session) prepareParams(params params) error {
@@ -303,8 +281,8 @@ func (session *session) commonHeaders() req.Header {
 }
 
 func (session *session) genNonce(timestamp int64) string {
-	session.nonceCount++
-	return fmt.Sprintf("%d%d%d", session.nonceCount, timestamp, rand.Int63n(timestamp))
+	count := atomic.AddInt64(session.nonceCount, 1)
+	return fmt.Sprintf("%d%d%d", count, timestamp, rand.Int63n(timestamp))
 }
 
 func (params *params) sign(secret string) error {
@@ -327,6 +305,55 @@ func (result *Result) success() bool {
 	return result.Code == 0
 }
 
+// APIError is returned for a result with a non-zero code.
+type APIError struct {
+	Code    int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error code:%d, message:%s", e.Code, e.Message)
+}
+
+// HTTPError is returned for a response with a status other than 200.
+type HTTPError struct {
+	StatusCode int
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http error code:%d", e.StatusCode)
+}
+
+// IsNotFound reports whether err reports a resource which does not exist,
+// by a 404 http status or one of the result codes.
+func IsNotFound(err error, codes ...int) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		for _, code := range codes {
+			if apiErr.Code == code {
+				return true
+			}
+		}
+		return false
+	}
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
+
+// checkResult converts a result with a non-zero code into an *APIError.
+func checkResult(result *Result, err error) (*Result, error) {
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, errors.New("empty result")
+	}
+	if !result.success() {
+		return nil, &APIError{Code: result.Code, Message: result.Message}
+	}
+	return result, nil
+}
+
 func (result *Result) checkSign(secret string) bool {
 	mySign, err := signHMACSHA256(result.Data, secret)
 	if err != nil {
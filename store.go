@@ -0,0 +1,166 @@
+package jadepoolsaas
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store persists the state of long running kyc workflows as json values.
+type Store interface {
+	// Load decodes the value stored under key into v, found is false if there is none.
+	Load(key string, v interface{}) (found bool, err error)
+	// Save stores v under key.
+	Save(key string, v interface{}) error
+	// Delete removes the value stored under key.
+	Delete(key string) error
+	// Keys returns the sorted keys starting with prefix.
+	Keys(prefix string) ([]string, error)
+}
+
+// MemoryStore is a Store keeping values in memory.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	values map[string][]byte
+}
+
+// NewMemoryStore creates an empty memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{values: make(map[string][]byte)}
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(key string, v interface{}) (bool, error) {
+	s.mu.RLock()
+	buf, ok := s.values[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(buf, v)
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(key string, v interface{}) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.values[key] = buf
+	s.mu.Unlock()
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	delete(s.values, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// Keys implements Store.
+func (s *MemoryStore) Keys(prefix string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []string
+	for key := range s.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// FileStore is a Store keeping every value in a json file of a directory.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a file store in dir, the directory is created if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Load implements Store.
+func (s *FileStore) Load(key string, v interface{}) (bool, error) {
+	buf, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(buf, v)
+}
+
+// Save implements Store, the file is replaced atomically.
+func (s *FileStore) Save(key string, v interface{}) error {
+	buf, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(s.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(buf)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path(key))
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Keys implements Store.
+func (s *FileStore) Keys(prefix string) ([]string, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		key, err := url.PathUnescape(strings.TrimSuffix(name, ".json"))
+		if err != nil || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key)+".json")
+}
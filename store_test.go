@@ -0,0 +1,48 @@
+package jadepoolsaas
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileStore, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, store := range []Store{NewMemoryStore(), fileStore} {
+		if err = store.Save("onboarding/individual/u1", &OnboardingState{ApplicationID: "a1"}); err != nil {
+			t.Fatal(err)
+		}
+		if err = store.Save("review/a2", map[string]string{"id": "a2"}); err != nil {
+			t.Fatal(err)
+		}
+
+		var state OnboardingState
+		found, err := store.Load("onboarding/individual/u1", &state)
+		if err != nil || !found || state.ApplicationID != "a1" {
+			t.Errorf("%T load = %+v, %v, %v; want a1", store, state, found, err)
+		}
+
+		keys, err := store.Keys("onboarding/")
+		if err != nil || len(keys) != 1 || keys[0] != "onboarding/individual/u1" {
+			t.Errorf("%T keys = %v, %v; want [onboarding/individual/u1]", store, keys, err)
+		}
+
+		if err = store.Delete("onboarding/individual/u1"); err != nil {
+			t.Fatal(err)
+		}
+		found, err = store.Load("onboarding/individual/u1", &state)
+		if err != nil || found {
+			t.Errorf("%T load deleted = %v, %v; want not found", store, found, err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package jadepoolsaas
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/nbltrust/hashkey-custody-sdk-go"
+
+// Tracing starts a client span named after the endpoint, such as
+// "jadepool.app.withdraw", for every call and injects the trace context
+// into the request headers with propagator. Path variables holding kyc
+// personal data, such as application identifiers, are redacted.
+func Tracing(tp trace.TracerProvider, propagator propagation.TextMapPropagator) Interceptor {
+	tracer := tp.Tracer(tracerName)
+	r := newRedactor(secretFields, personalFields)
+	return func(next RoundTrip) RoundTrip {
+		return func(call *Call) error {
+			ctx, span := tracer.Start(call.Context(), "jadepool."+call.Endpoint,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("http.request.method", call.Method),
+					attribute.String("url.path", r.path(call)),
+				),
+			)
+			defer span.End()
+
+			if coinType := callCoinType(call); coinType != "" {
+				span.SetAttributes(attribute.String("jadepool.coin_type", coinType))
+			}
+			propagator.Inject(ctx, propagation.HeaderCarrier(call.Header))
+			call.SetContext(ctx)
+
+			err := next(call)
+
+			span.SetAttributes(
+				attribute.Int("http.response.status_code", call.StatusCode),
+				attribute.Int("jadepool.retry_count", call.Retries),
+			)
+			if call.Result != nil {
+				span.SetAttributes(attribute.Int("jadepool.code", call.Result.Code))
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
+
+// WithTracerProvider traces every call of the client with spans from tp and
+// propagates W3C trace context and baggage headers.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	propagator := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	return WithInterceptors(Tracing(tp, propagator))
+}
+
+func callCoinType(call *Call) string {
+	if coinType := call.vars["coinType"]; coinType != "" {
+		return coinType
+	}
+	for _, key := range []string{"assetName", "coinName"} {
+		if val, ok := call.Params[key]; ok {
+			return fmt.Sprint(val)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package jadepoolsaas
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	response := map[string]interface{}{}
+
+	queryHandler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("traceparent") == "" {
+			t.Error("traceparent header is empty")
+		}
+
+		_, err := writeSuccessResponse(w, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(queryHandler))
+	defer ts.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+
+	app := NewAppWithAddr(ts.URL, TestAppKey, TestAppSecret, WithTracerProvider(tp))
+	_, err := app.WithContext(ctx).Withdraw("1", "ETH", "0x7C3A4d3ff2b92CFDD2eD1a105d5bAc8fAF4008aE", "0.01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("spans = %d; want 2", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "jadepool.app.withdraw" {
+		t.Errorf("span name = %s; want jadepool.app.withdraw", span.Name)
+	}
+	if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Error("span is not a child of the context span")
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, attr := range span.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	if attrs["jadepool.coin_type"].AsString() != "ETH" {
+		t.Errorf("coin type = %v; want ETH", attrs["jadepool.coin_type"])
+	}
+	if attrs["http.response.status_code"].AsInt64() != 200 {
+		t.Errorf("status = %v; want 200", attrs["http.response.status_code"])
+	}
+	if _, ok := attrs["jadepool.code"]; !ok {
+		t.Error("business code attribute is missing")
+	}
+	if _, ok := attrs["jadepool.retry_count"]; !ok {
+		t.Error("retry count attribute is missing")
+	}
+}
+
+func TestTracingRedactsPath(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := writeSuccessResponse(w, map[string]interface{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer ts.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	kyc := NewKYCWithAddr(ts.URL, TestAppKey, TestAppSecret, WithTracerProvider(tp))
+	if _, err := kyc.ApplicationGetByIdentifier(ApplicationTypeIndividual, "alice@example.com", false); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("spans = %d; want 1", len(spans))
+	}
+	for _, attr := range spans[0].Attributes {
+		if attr.Key == "url.path" && attr.Value.AsString() != "/api/v1/application/identifier/individual/REDACTED" {
+			t.Errorf("url.path = %s; want redacted identifier", attr.Value.AsString())
+		}
+	}
+}
@@ -1,12 +1,24 @@
 package jadepoolsaas
 
+import "encoding/json"
+
 const (
 	defaultAddr = "https://openapi.jadepool.io"
 )
 
 type client interface {
+	getKind() string
 	getKey() string
 	getKeyHeaderName() string
 	getSecret() string
 	getAddr() string
 }
+
+// decodeData converts the data of a result into a typed value.
+func decodeData(data interface{}, v interface{}) error {
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
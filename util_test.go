@@ -28,3 +28,23 @@ func writeSuccessResponse(w http.ResponseWriter, data map[string]interface{}) (i
 
 	return w.Write(result)
 }
+
+func writeErrorResponse(w http.ResponseWriter, code int, message string) (int, error) {
+	data := map[string]interface{}{}
+	sign, err := signHMACSHA256(data, TestAppSecret)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := json.Marshal(&map[string]interface{}{
+		"code":    code,
+		"data":    data,
+		"message": message,
+		"sign":    sign,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return w.Write(result)
+}
@@ -0,0 +1,101 @@
+package jadepoolsaas
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FieldError describes a missing or invalid field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors lists every invalid field found by a validation.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fieldErr := range e {
+		msgs[i] = fieldErr.Error()
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// validator collects field errors under a path prefix.
+type validator struct {
+	prefix string
+	errs   *ValidationErrors
+}
+
+func newValidator() *validator {
+	return &validator{errs: &ValidationErrors{}}
+}
+
+func (v *validator) nested(field string) *validator {
+	return &validator{prefix: v.field(field), errs: v.errs}
+}
+
+func (v *validator) field(field string) string {
+	if v.prefix == "" {
+		return field
+	}
+	if strings.HasPrefix(field, "[") {
+		return v.prefix + field
+	}
+	return v.prefix + "." + field
+}
+
+func (v *validator) add(field, message string) {
+	*v.errs = append(*v.errs, FieldError{Field: v.field(field), Message: message})
+}
+
+func (v *validator) required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+var countryCodeRegexp = regexp.MustCompile(`^[A-Z]{2}$`)
+
+func (v *validator) country(field, value string) {
+	if v.required(field, value) && !countryCodeRegexp.MatchString(value) {
+		v.add(field, "must be an ISO 3166-1 alpha-2 country code")
+	}
+}
+
+func (v *validator) pastDate(field, value string) {
+	if !v.required(field, value) {
+		return
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		v.add(field, "must be a date formatted as YYYY-MM-DD")
+	} else if date.After(time.Now()) {
+		v.add(field, "must not be in the future")
+	}
+}
+
+func (v *validator) email(field, value string) {
+	if value == "" {
+		return
+	}
+	if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+		v.add(field, "must be a valid email address")
+	}
+}
+
+func (v *validator) err() error {
+	if len(*v.errs) == 0 {
+		return nil
+	}
+	return *v.errs
+}